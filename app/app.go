@@ -0,0 +1,136 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yorukot/go-template/app/models"
+	"github.com/yorukot/go-template/app/repository"
+	"github.com/yorukot/go-template/migrations"
+	"github.com/yorukot/go-template/pkg/cache"
+	"github.com/yorukot/go-template/pkg/config"
+	db "github.com/yorukot/go-template/pkg/database"
+	"github.com/yorukot/go-template/pkg/encryption"
+	"github.com/yorukot/go-template/pkg/health"
+	"github.com/yorukot/go-template/pkg/logger"
+	oauth "github.com/yorukot/go-template/pkg/oauth"
+	store "github.com/yorukot/go-template/pkg/s3"
+	"github.com/yorukot/go-template/pkg/shutdown"
+	"github.com/yorukot/go-template/pkg/storage"
+	"github.com/yorukot/go-template/pkg/tracing"
+	"github.com/yorukot/go-template/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// App holds the configuration and the connections shared by every handler.
+// It is built once in main and passed to route registration, so no package
+// connects to anything when it is imported.
+type App struct {
+	Config *config.Config
+	DB     *gorm.DB
+	Cache  *cache.Cache // nil unless CACHE_ENABLED
+	Store  *store.Store // nil unless S3_ENABLED
+	// Public keeps the files readable by anyone, like the avatars, and
+	// Private those handed out through signed URLs, like the data exports.
+	// STORAGE_BACKEND selects where they are kept.
+	Public   *storage.Public
+	Private  storage.Storage
+	Tx       repository.Transactor
+	Users    repository.UserRepository
+	Sessions repository.SessionRepository
+	Exports  repository.ExportRepository
+	Uploads  repository.UploadRepository
+}
+
+// New configures the shared packages, opens every enabled connection and
+// registers their readiness checks and shutdown hooks
+func New(ctx context.Context, cfg *config.Config) (*App, error) {
+	logger.Init(cfg)
+	encryption.Init(cfg)
+	utils.Init(cfg)
+
+	shutdownTracing, err := tracing.Init(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	shutdown.Register(shutdown.PhaseTelemetry, "tracing", shutdownTracing)
+
+	a := &App{Config: cfg}
+
+	if a.DB, err = db.Open(ctx, cfg.Database); err != nil {
+		return nil, err
+	}
+	health.Register("database", func(ctx context.Context) error {
+		return db.Ping(ctx, a.DB)
+	})
+	shutdown.Register(shutdown.PhaseDatabase, "database", func(context.Context) error {
+		return db.Close(a.DB)
+	})
+
+	if err := checkSchema(ctx, a.DB, cfg.Database); err != nil {
+		return nil, err
+	}
+	a.Tx = repository.NewGormTransactor(a.DB)
+	a.Users = repository.NewGormUserRepository(a.DB)
+	a.Sessions = repository.NewGormSessionRepository(a.DB)
+	a.Exports = repository.NewGormExportRepository(a.DB)
+	a.Uploads = repository.NewGormUploadRepository(a.DB)
+
+	if cfg.Cache.Enabled {
+		if a.Cache, err = cache.New(cfg.Cache); err != nil {
+			return nil, err
+		}
+		health.Register("redis", func(ctx context.Context) error {
+			return a.Cache.Client.Ping(ctx).Err()
+		})
+		health.Register("redis_limiter", func(ctx context.Context) error {
+			return a.Cache.Limiter.Ping(ctx).Err()
+		})
+		shutdown.Register(shutdown.PhaseCache, "redis", func(context.Context) error {
+			return a.Cache.Close()
+		})
+	}
+
+	if cfg.S3.Enabled {
+		if a.Store, err = store.New(ctx, cfg.S3); err != nil {
+			return nil, err
+		}
+		health.Register("s3", a.Store.Ping)
+	}
+	if a.Public, a.Private, err = storage.Open(cfg, a.Store, utils.BackendURL); err != nil {
+		return nil, err
+	}
+
+	if cfg.OAuth.Enabled {
+		oauth.Init(cfg.OAuth)
+	}
+
+	return a, nil
+}
+
+// checkSchema syncs the schema from the models in the AutoMigrate development
+// mode. Otherwise the schema is owned by the versioned migrations, which are
+// applied with "migrate up" instead of at startup so replicas never race, and
+// pending migrations are only reported.
+func checkSchema(ctx context.Context, conn *gorm.DB, cfg config.DatabaseConfig) error {
+	if cfg.AutoMigrate {
+		logger.Log.Warn("DATABASE_AUTO_MIGRATE is enabled, use versioned migrations outside development")
+		if err := models.AutoMigrate(conn); err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+		return nil
+	}
+
+	migrator, err := db.NewMigrator(conn, cfg.Type, migrations.FS, cfg.MigrationLockTimeout)
+	if err != nil {
+		return err
+	}
+	pending, err := migrator.Pending(db.WithPrimary(ctx))
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		logger.Log.Sugar().Warnf("%d database migrations are pending, run \"migrate up\"", pending)
+	}
+	return nil
+}
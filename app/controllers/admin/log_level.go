@@ -0,0 +1,46 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/pkg/logger"
+	"github.com/yorukot/go-template/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// LogLevelRequest represents the request body for changing the log level
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required,oneof=debug info warn error dpanic panic fatal"`
+}
+
+// LogLevelResponse represents the current log level
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// GetLogLevel returns the current log level
+func GetLogLevel(c *gin.Context) {
+	utils.FullyResponse(c, 200, "Log level acquired", nil, LogLevelResponse{Level: logger.GetLevel()})
+}
+
+// SetLogLevel changes the log level at runtime
+func SetLogLevel(c *gin.Context) {
+	var request LogLevelRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	previous := logger.GetLevel()
+	if err := logger.SetLevel(request.Level); err != nil {
+		utils.FullyResponse(c, 400, err.Error(), utils.ErrBadRequest, nil)
+		return
+	}
+
+	// Log at warn so the change is recorded whatever the new level is
+	logger.FromContext(c).Warn("Log level changed",
+		zap.String("previous", previous),
+		zap.String("level", logger.GetLevel()),
+	)
+
+	utils.FullyResponse(c, 200, "Log level updated", nil, LogLevelResponse{Level: logger.GetLevel()})
+}
@@ -0,0 +1,314 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/app/models"
+	"github.com/yorukot/go-template/app/repository"
+	"github.com/yorukot/go-template/pkg/config"
+	"github.com/yorukot/go-template/pkg/encryption"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+// TestMain sets the settings read by the handlers, with cheap password hashing
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		App:     config.AppConfig{BaseURL: "http://localhost:8080", Version: "1"},
+		Argon2:  config.Argon2Config{Memory: 64, Iterations: 1, Parallelism: 1},
+		Cookie:  config.CookieConfig{Path: "/", RefreshTokenExpires: 60, AccessTokenExpires: 15},
+		Account: config.AccountConfig{DeletionGracePeriod: 30 * 24 * time.Hour},
+	}
+	config.Set(cfg)
+	utils.Init(cfg)
+	encryption.JwtSecretKey = "test secret"
+	os.Exit(m.Run())
+}
+
+// newTestHandler returns a handler on empty memory repositories
+func newTestHandler() (*Handler, *repository.MemoryUserRepository, *repository.MemorySessionRepository) {
+	users, sessions := repository.NewMemoryUserRepository(), repository.NewMemorySessionRepository()
+	return NewHandler(repository.MemoryTransactor{}, users, sessions), users, sessions
+}
+
+// response is the body written by utils.FullyResponse
+type response struct {
+	Message string `json:"message"`
+	Error   string `json:"error"`
+}
+
+// serve calls the handler with the JSON body and decodes the response
+func serve(t *testing.T, handler gin.HandlerFunc, body string) (*httptest.ResponseRecorder, response) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	handler(c)
+
+	var res response
+	if err := json.Unmarshal(recorder.Body.Bytes(), &res); err != nil {
+		t.Fatalf("invalid response body %q: %v", recorder.Body.String(), err)
+	}
+	return recorder, res
+}
+
+// createUser stores a user with the password, hashed
+func createUser(t *testing.T, users repository.UserRepository, email string, password string) models.User {
+	t.Helper()
+	hash, err := encryption.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := models.User{ID: encryption.GenerateID(), DisplayName: "user", Email: email, Password: hash, Language: "en"}
+	if err := users.Create(context.Background(), &user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// hasCookie reports whether the response sets the cookie to a value
+func hasCookie(recorder *httptest.ResponseRecorder, name string) bool {
+	for _, cookie := range recorder.Result().Cookies() {
+		if cookie.Name == name && cookie.Value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSignup(t *testing.T) {
+	t.Run("creates the user and its session", func(t *testing.T) {
+		h, users, sessions := newTestHandler()
+		recorder, res := serve(t, h.Signup, `{"display_name":"alice","email":"alice@example.com","password":"password123"}`)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("got status %d %+v, want 200", recorder.Code, res)
+		}
+
+		user, err := users.GetByEmail(context.Background(), "alice@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match, _ := encryption.ComparePasswordAndHash("password123", user.Password); !match {
+			t.Error("stored password does not match")
+		}
+		if list, _ := sessions.ListByUserID(context.Background(), user.ID); len(list) != 1 {
+			t.Errorf("got %d sessions, want 1", len(list))
+		}
+		if !hasCookie(recorder, "refresh_token") || !hasCookie(recorder, "access_token") {
+			t.Error("session cookies not set")
+		}
+	})
+
+	t.Run("rejects a used email", func(t *testing.T) {
+		h, users, _ := newTestHandler()
+		createUser(t, users, "alice@example.com", "password123")
+		recorder, res := serve(t, h.Signup, `{"display_name":"alice","email":"alice@example.com","password":"password123"}`)
+		if recorder.Code != http.StatusBadRequest || res.Error != utils.ErrEmailAlreadyUsed {
+			t.Errorf("got status %d %+v, want 400 %s", recorder.Code, res, utils.ErrEmailAlreadyUsed)
+		}
+	})
+
+	t.Run("rejects a reserved email", func(t *testing.T) {
+		utils.ReserveDeletedEmails = true
+		defer func() { utils.ReserveDeletedEmails = false }()
+
+		h, users, _ := newTestHandler()
+		if err := users.ReserveEmail(context.Background(), "alice@example.com"); err != nil {
+			t.Fatal(err)
+		}
+		recorder, res := serve(t, h.Signup, `{"display_name":"alice","email":"alice@example.com","password":"password123"}`)
+		if recorder.Code != http.StatusBadRequest || res.Error != utils.ErrEmailAlreadyUsed {
+			t.Errorf("got status %d %+v, want 400 %s", recorder.Code, res, utils.ErrEmailAlreadyUsed)
+		}
+	})
+
+	t.Run("rejects an invalid request", func(t *testing.T) {
+		h, _, _ := newTestHandler()
+		recorder, res := serve(t, h.Signup, `{"display_name":"alice","email":"alice@example.com","password":"short"}`)
+		if recorder.Code != http.StatusBadRequest || res.Error != utils.ErrBadRequest {
+			t.Errorf("got status %d %+v, want 400 %s", recorder.Code, res, utils.ErrBadRequest)
+		}
+	})
+}
+
+func TestLogin(t *testing.T) {
+	const body = `{"email":"alice@example.com","password":"password123"}`
+
+	tests := []struct {
+		name    string
+		setup   func(user *models.User) // changes the stored user, nil for no user
+		status  int
+		code    string
+		session bool
+	}{
+		{name: "valid credentials", setup: func(*models.User) {}, status: http.StatusOK, session: true},
+		{name: "unknown email", status: http.StatusBadRequest, code: utils.ErrInvalidUsernameOrEmail},
+		{
+			name:   "wrong password",
+			setup:  func(user *models.User) { user.Password, _ = encryption.HashPassword("another password") },
+			status: http.StatusBadRequest,
+			code:   utils.ErrInvalidPassword,
+		},
+		{
+			name:   "account without password",
+			setup:  func(user *models.User) { user.Password = "" },
+			status: http.StatusBadRequest,
+			code:   utils.ErrInvalidPassword,
+		},
+		{
+			name: "disabled account",
+			setup: func(user *models.User) {
+				disabledAt := time.Now()
+				user.DisabledAt = &disabledAt
+			},
+			status: http.StatusForbidden,
+			code:   utils.ErrAccountDisabled,
+		},
+		{
+			name: "deleted account in the grace period",
+			setup: func(user *models.User) {
+				deletedAt := time.Now().Add(-time.Hour)
+				user.DeletedAt = &deletedAt
+			},
+			status:  http.StatusOK,
+			session: true,
+		},
+		{
+			name: "deleted account past the grace period",
+			setup: func(user *models.User) {
+				deletedAt := time.Now().Add(-utils.AccountDeletionGracePeriod - time.Hour)
+				user.DeletedAt = &deletedAt
+			},
+			status: http.StatusBadRequest,
+			code:   utils.ErrInvalidUsernameOrEmail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, users, sessions := newTestHandler()
+			var user models.User
+			if tt.setup != nil {
+				user = createUser(t, users, "alice@example.com", "password123")
+				tt.setup(&user)
+				if err := users.Update(context.Background(), &user); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			recorder, res := serve(t, h.Login, body)
+			if recorder.Code != tt.status || res.Error != tt.code {
+				t.Fatalf("got status %d %+v, want %d %q", recorder.Code, res, tt.status, tt.code)
+			}
+
+			list, _ := sessions.ListByUserID(context.Background(), user.ID)
+			if (len(list) == 1) != tt.session || hasCookie(recorder, "refresh_token") != tt.session {
+				t.Errorf("got %d sessions, want a session: %t", len(list), tt.session)
+			}
+			if tt.status == http.StatusOK {
+				if stored, _ := users.GetByID(context.Background(), user.ID); stored.DeletedAt != nil {
+					t.Error("deleted account not restored")
+				}
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	tests := []struct {
+		name     string
+		noCookie bool
+		cookie   string
+		setup    func(user *models.User, session *models.Session)
+		status   int
+		code     string
+		refresh  bool
+	}{
+		{name: "valid session", status: http.StatusOK, refresh: true},
+		{name: "missing cookie", noCookie: true, status: http.StatusUnauthorized, code: utils.ErrAuthenticationKeyNotFound},
+		{name: "unknown refresh token", cookie: "unknown", status: http.StatusUnauthorized, code: utils.ErrUnauthorized},
+		{
+			name: "expired session",
+			setup: func(_ *models.User, session *models.Session) {
+				session.ExpiresAt = time.Now().Add(-time.Minute)
+			},
+			status: http.StatusUnauthorized,
+			code:   utils.ErrTokenExpired,
+		},
+		{
+			name: "deleted account",
+			setup: func(user *models.User, _ *models.Session) {
+				deletedAt := time.Now()
+				user.DeletedAt = &deletedAt
+			},
+			status: http.StatusUnauthorized,
+			code:   utils.ErrUnauthorized,
+		},
+		{
+			name: "disabled account",
+			setup: func(user *models.User, _ *models.Session) {
+				disabledAt := time.Now()
+				user.DisabledAt = &disabledAt
+			},
+			status: http.StatusForbidden,
+			code:   utils.ErrAccountDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, users, sessions := newTestHandler()
+			user := createUser(t, users, "alice@example.com", "password123")
+			session, err := utils.CreateUserSession(context.Background(), sessions, user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.setup != nil {
+				tt.setup(&user, &session)
+				if err := users.Update(context.Background(), &user); err != nil {
+					t.Fatal(err)
+				}
+				if err := sessions.DeleteBySecretKey(context.Background(), session.SecretKey); err != nil {
+					t.Fatal(err)
+				}
+				if err := sessions.Create(context.Background(), &session); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.cookie == "" {
+				tt.cookie = session.SecretKey
+			}
+			if !tt.noCookie {
+				c.Request.AddCookie(&http.Cookie{Name: "refresh_token", Value: tt.cookie})
+			}
+			h.Refresh(c)
+
+			var res response
+			if err := json.Unmarshal(recorder.Body.Bytes(), &res); err != nil {
+				t.Fatalf("invalid response body %q: %v", recorder.Body.String(), err)
+			}
+			if recorder.Code != tt.status || res.Error != tt.code {
+				t.Fatalf("got status %d %+v, want %d %q", recorder.Code, res, tt.status, tt.code)
+			}
+			if hasCookie(recorder, "access_token") != tt.refresh {
+				t.Errorf("access token set: %t, want %t", !tt.refresh, tt.refresh)
+			}
+			if hasCookie(recorder, "refresh_token") {
+				t.Error("refresh token rotated")
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+// CSRFTokenResponse represents the response body for the csrf token request
+type CSRFTokenResponse struct {
+	CSRFToken string `json:"csrf_token"`
+}
+
+// GetCSRFToken returns the csrf token issued by the CSRF middleware so the
+// frontend can send it back in the X-CSRF-Token header
+func GetCSRFToken(c *gin.Context) {
+	utils.FullyResponse(c, 200, "CSRF token acquired", nil, CSRFTokenResponse{
+		CSRFToken: c.GetString("csrfToken"),
+	})
+}
@@ -0,0 +1,17 @@
+package auth
+
+import (
+	"github.com/yorukot/go-template/app/repository"
+)
+
+// Handler serves the authentication endpoints
+type Handler struct {
+	tx       repository.Transactor
+	users    repository.UserRepository
+	sessions repository.SessionRepository
+}
+
+// NewHandler creates the authentication handler
+func NewHandler(tx repository.Transactor, users repository.UserRepository, sessions repository.SessionRepository) *Handler {
+	return &Handler{tx: tx, users: users, sessions: sessions}
+}
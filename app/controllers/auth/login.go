@@ -2,13 +2,14 @@ package auth
 
 import (
 	"errors"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yorukot/go-template/app/models"
-	"github.com/yorukot/go-template/app/queries"
+	"github.com/yorukot/go-template/app/repository"
 	"github.com/yorukot/go-template/pkg/encryption"
+	"github.com/yorukot/go-template/pkg/metrics"
 	"github.com/yorukot/go-template/pkg/utils"
-	"gorm.io/gorm"
 )
 
 // EmailLoginRequest represents the request body for login
@@ -18,13 +19,13 @@ type EmailLoginRequest struct {
 }
 
 // Login handles the user login process
-func Login(c *gin.Context) {
+func (h *Handler) Login(c *gin.Context) {
 	request, err := validateLoginRequest(c)
 	if err != nil {
 		return // Error response already sent in the validation function
 	}
 
-	user, err := fetchUserByEmail(c, request.Email)
+	user, err := h.fetchUserByEmail(c, request.Email)
 	if err != nil {
 		return // Error response already sent in the fetch function
 	}
@@ -33,11 +34,53 @@ func Login(c *gin.Context) {
 		return // Error response already sent in the validation function
 	}
 
-	if err := generateUserSession(c, user.ID); err != nil {
+	// Checked after the password so the state of an account is not disclosed to guessers
+	if user.DisabledAt != nil {
+		metrics.AuthLoginFailures.WithLabelValues("disabled").Inc()
+		utils.FullyResponse(c, 403, "Account is disabled", utils.ErrAccountDisabled, nil)
+		return
+	}
+
+	message := "Login successful"
+	if user.DeletedAt != nil {
+		if err := h.restoreUser(c, &user); err != nil {
+			return // Error response already sent in the restore function
+		}
+		message = "Login successful, account restored"
+	}
+
+	if err := h.generateUserSession(c, user.ID); err != nil {
 		return // Error response already sent in the session function
 	}
 
-	utils.FullyResponse(c, 200, "Login successful", nil, nil)
+	metrics.AuthLogins.Inc()
+	utils.FullyResponse(c, 200, message, nil, nil)
+}
+
+// restoreUser cancels the deletion of an account during its grace period.
+// Past the grace period the account is only waiting for the purge, so it is
+// reported like a missing account.
+func (h *Handler) restoreUser(c *gin.Context, user *models.User) error {
+	if time.Since(*user.DeletedAt) >= utils.AccountDeletionGracePeriod {
+		metrics.AuthLoginFailures.WithLabelValues("deleted").Inc()
+		utils.FullyResponse(c, 400, "Invalid email", utils.ErrInvalidUsernameOrEmail, nil)
+		return errors.New("account deleted")
+	}
+
+	user.UpdatedAt = time.Now()
+	err := h.users.Restore(c.Request.Context(), user)
+	if errors.Is(err, repository.ErrNotFound) {
+		// Purged since it was fetched
+		metrics.AuthLoginFailures.WithLabelValues("deleted").Inc()
+		utils.FullyResponse(c, 400, "Invalid email", utils.ErrInvalidUsernameOrEmail, nil)
+		return err
+	} else if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error restore account", utils.ErrSaveData, err)
+		return err
+	}
+
+	metrics.AccountRestores.Inc()
+	return nil
 }
 
 // validateLoginRequest validates the incoming login request
@@ -58,14 +101,15 @@ func validateLoginRequest(c *gin.Context) (*EmailLoginRequest, error) {
 }
 
 // fetchUserByEmail retrieves the user by email from the database
-func fetchUserByEmail(c *gin.Context, email string) (models.User, error) {
-	user, result := queries.GetUserQueueByEmail(email)
-	if result.Error == gorm.ErrRecordNotFound {
+func (h *Handler) fetchUserByEmail(c *gin.Context, email string) (models.User, error) {
+	user, err := h.users.GetByEmail(c.Request.Context(), email)
+	if errors.Is(err, repository.ErrNotFound) {
+		metrics.AuthLoginFailures.WithLabelValues("invalid_email").Inc()
 		utils.FullyResponse(c, 400, "Invalid email", utils.ErrInvalidUsernameOrEmail, nil)
-		return models.User{}, result.Error
-	} else if result.Error != nil {
-		utils.ServerErrorResponse(c, 500, "Error check email", utils.ErrGetData, result.Error)
-		return models.User{}, result.Error
+		return models.User{}, err
+	} else if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error check email", utils.ErrGetData, err)
+		return models.User{}, err
 	}
 
 	return user, nil
@@ -74,12 +118,14 @@ func fetchUserByEmail(c *gin.Context, email string) (models.User, error) {
 // validateUserPassword verifies if the provided password matches the stored hash
 func validateUserPassword(c *gin.Context, user models.User, password string) error {
 	if user.Password == "" {
+		metrics.AuthLoginFailures.WithLabelValues("no_password").Inc()
 		utils.FullyResponse(c, 400, "Invalid password", utils.ErrInvalidPassword, nil)
 		return errors.New("invalid password")
 	}
 
 	match, err := encryption.ComparePasswordAndHash(password, user.Password)
 	if err != nil || !match {
+		metrics.AuthLoginFailures.WithLabelValues("invalid_password").Inc()
 		utils.FullyResponse(c, 400, "Invalid password", utils.ErrInvalidPassword, nil)
 		return errors.New("invalid password")
 	}
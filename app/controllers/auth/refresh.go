@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/app/models"
+	"github.com/yorukot/go-template/app/repository"
+	"github.com/yorukot/go-template/pkg/metrics"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+// Refresh issues a new access token for the session of the refresh_token cookie
+func (h *Handler) Refresh(c *gin.Context) {
+	session, err := h.fetchRefreshSession(c)
+	if err != nil {
+		return // Error response already sent in the fetch function
+	}
+
+	user, err := h.users.GetByID(c.Request.Context(), session.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.FullyResponse(c, 401, "Invalid refresh token", utils.ErrUnauthorized, nil)
+		return
+	} else if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error get user", utils.ErrGetData, err)
+		return
+	}
+
+	// Deleted accounts are restored by logging in, not by an old session
+	if user.DeletedAt != nil {
+		utils.FullyResponse(c, 401, "Invalid refresh token", utils.ErrUnauthorized, nil)
+		return
+	}
+	if user.DisabledAt != nil {
+		utils.FullyResponse(c, 403, "Account is disabled", utils.ErrAccountDisabled, nil)
+		return
+	}
+
+	if err := utils.GenerateAccessToken(c, user.ID); err != nil {
+		utils.ServerErrorResponse(c, 500, "Error generate access token", utils.ErrGenerateToken, err)
+		return
+	}
+
+	metrics.AuthRefreshes.Inc()
+	utils.FullyResponse(c, 200, "Access token refreshed", nil, nil)
+}
+
+// fetchRefreshSession retrieves the unexpired session of the refresh_token cookie
+func (h *Handler) fetchRefreshSession(c *gin.Context) (models.Session, error) {
+	cookie, err := c.Request.Cookie("refresh_token")
+	if err != nil || cookie.Value == "" {
+		utils.FullyResponse(c, 401, "Refresh token not found", utils.ErrAuthenticationKeyNotFound, nil)
+		return models.Session{}, errors.New("refresh token not found")
+	}
+
+	session, err := h.sessions.GetBySecretKey(c.Request.Context(), cookie.Value)
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.FullyResponse(c, 401, "Invalid refresh token", utils.ErrUnauthorized, nil)
+		return models.Session{}, err
+	} else if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error get session", utils.ErrGetData, err)
+		return models.Session{}, err
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		utils.FullyResponse(c, 401, "Refresh token expired", utils.ErrTokenExpired, nil)
+		return models.Session{}, errors.New("refresh token expired")
+	}
+
+	return session, nil
+}
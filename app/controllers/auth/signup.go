@@ -1,14 +1,16 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yorukot/go-template/app/models"
-	"github.com/yorukot/go-template/app/queries"
+	"github.com/yorukot/go-template/app/repository"
 	"github.com/yorukot/go-template/pkg/encryption"
+	"github.com/yorukot/go-template/pkg/metrics"
 	"github.com/yorukot/go-template/pkg/utils"
-	"gorm.io/gorm"
 )
 
 // EmailAuthRequest represents the request body for signup
@@ -19,34 +21,47 @@ type EmailAuthRequest struct {
 }
 
 // Signup handles the user registration process
-func Signup(c *gin.Context) {
+func (h *Handler) Signup(c *gin.Context) {
 	request, err := validateSignupRequest(c)
 	if err != nil {
 		return // Error response already sent in the validation function
 	}
 
-	if err := checkEmailAvailability(c, request.Email); err != nil {
-		return // Error response already sent in the check function
-	}
-
-	if err := checkUsernameAvailability(c); err != nil {
-		return // Error response already sent in the check function
-	}
-
 	user := createUserModel(request)
 
+	// Hash before the transaction, which would otherwise hold its locks
+	// for the whole key derivation
 	if err := hashUserPassword(c, &user); err != nil {
 		return // Error response already sent in the hash function
 	}
 
-	if err := saveUserToQueue(c, user); err != nil {
-		return // Error response already sent in the save function
+	// The email check, the user and its session are one unit of work, so a
+	// failure never leaves a user without a session
+	var session models.Session
+	err = h.tx.WithTx(c.Request.Context(), func(ctx context.Context) error {
+		if err := h.checkEmailAvailability(ctx, c, request.Email); err != nil {
+			return err // Error response already sent in the check function
+		}
+		if err := h.saveUserToQueue(ctx, c, user); err != nil {
+			return err // Error response already sent in the save function
+		}
+		var err error
+		session, err = h.createUserSession(ctx, c, user.ID)
+		return err // Error response already sent in the session function
+	})
+	if err != nil {
+		if !c.Writer.Written() {
+			utils.ServerErrorResponse(c, 500, "Error save new user", utils.ErrSaveData, err)
+		}
+		return
 	}
 
-	if err := generateUserSession(c, user.ID); err != nil {
-		return // Error response already sent in the session function
+	if err := utils.SetSessionCookies(c, session); err != nil {
+		utils.ServerErrorResponse(c, 500, "Error generate user session", utils.ErrGenerateSession, err)
+		return
 	}
 
+	metrics.AuthSignups.Inc()
 	utils.FullyResponse(c, 200, "Signup successful please verify email", nil, nil)
 }
 
@@ -62,34 +77,28 @@ func validateSignupRequest(c *gin.Context) (*EmailAuthRequest, error) {
 	return &request, nil
 }
 
-// checkEmailAvailability verifies if the email is already in use
-func checkEmailAvailability(c *gin.Context, email string) error {
-	_, result := queries.GetUserQueueByEmail(email)
-	if result.Error == nil {
+// checkEmailAvailability verifies if the email is already in use or reserved
+// by a purged account, so the common case is answered without a failed
+// insert. Concurrent signups are settled by the unique email constraint.
+func (h *Handler) checkEmailAvailability(ctx context.Context, c *gin.Context, email string) error {
+	_, err := h.users.GetByEmail(ctx, email)
+	if err == nil {
 		utils.FullyResponse(c, 400, "Email already been used", utils.ErrEmailAlreadyUsed, nil)
-		return result.Error
-	} else if result.Error != gorm.ErrRecordNotFound {
-		utils.ServerErrorResponse(c, 500, "Error checking email", utils.ErrGetData, result.Error)
-		return result.Error
+		return errors.New("email already been used")
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		utils.ServerErrorResponse(c, 500, "Error checking email", utils.ErrGetData, err)
+		return err
 	}
 
-	return nil
-}
-
-// checkUsernameAvailability verifies if the username is already in use
-func checkUsernameAvailability(c *gin.Context) error {
-	// Note: The original code seems to have an incomplete check for username
-	// It checks if result.Error == nil but doesn't have the query before it
-	// I'm preserving the original logic, but this might need review
-
-	result := &gorm.DB{Error: gorm.ErrRecordNotFound} // Simulating the intended behavior
-
-	if result.Error == nil {
-		utils.FullyResponse(c, 400, "Username already been used", utils.ErrUsernameAlreadyUsed, nil)
-		return result.Error
-	} else if result.Error != gorm.ErrRecordNotFound {
-		utils.ServerErrorResponse(c, 500, "Error checking username", utils.ErrGetData, result.Error)
-		return result.Error
+	if utils.ReserveDeletedEmails {
+		reserved, err := h.users.IsEmailReserved(ctx, email)
+		if err != nil {
+			utils.ServerErrorResponse(c, 500, "Error checking email", utils.ErrGetData, err)
+			return err
+		} else if reserved {
+			utils.FullyResponse(c, 400, "Email already been used", utils.ErrEmailAlreadyUsed, nil)
+			return errors.New("email is reserved")
+		}
 	}
 
 	return nil
@@ -119,18 +128,33 @@ func hashUserPassword(c *gin.Context, user *models.User) error {
 }
 
 // saveUserToQueue saves the new user to the user queue
-func saveUserToQueue(c *gin.Context, user models.User) error {
-	result := queries.CreateUserQueue(user)
-	if result.Error != nil || result.RowsAffected == 0 {
-		utils.ServerErrorResponse(c, 500, "Error create new user", utils.ErrSaveData, result.Error)
-		return result.Error
+func (h *Handler) saveUserToQueue(ctx context.Context, c *gin.Context, user models.User) error {
+	err := h.users.Create(ctx, &user)
+	if errors.Is(err, repository.ErrEmailAlreadyUsed) {
+		// Another signup with the same email won the race since the availability check
+		utils.FullyResponse(c, 400, "Email already been used", utils.ErrEmailAlreadyUsed, nil)
+		return err
+	} else if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error create new user", utils.ErrSaveData, err)
+		return err
 	}
 	return nil
 }
 
-// generateUserSession creates a session for the newly registered user
-func generateUserSession(c *gin.Context, userID uint64) error {
-	err := utils.GenerateUserSession(c, userID)
+// createUserSession creates a session for the newly registered user, its
+// cookies are set once the transaction commits
+func (h *Handler) createUserSession(ctx context.Context, c *gin.Context, userID uint64) (models.Session, error) {
+	session, err := utils.CreateUserSession(ctx, h.sessions, userID)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error generate user session", utils.ErrGenerateSession, err)
+		return models.Session{}, err
+	}
+	return session, nil
+}
+
+// generateUserSession creates a session for the user and sets its cookies
+func (h *Handler) generateUserSession(c *gin.Context, userID uint64) error {
+	err := utils.GenerateUserSession(c, h.sessions, userID)
 	if err != nil {
 		utils.ServerErrorResponse(c, 500, "Error generate user session", utils.ErrGenerateSession, err)
 		return err
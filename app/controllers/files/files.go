@@ -0,0 +1,79 @@
+package files
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/pkg/storage"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+// Download serves the objects of a private storage through the links it
+// signed, the signature stands in for authentication
+func Download(files storage.Storage, verifier storage.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimPrefix(c.Param("key"), "/")
+		if err := verifier.Verify(key, c.Query("expires"), c.Query("signature")); err != nil {
+			utils.FullyResponse(c, 404, "File not found or link expired", utils.ErrGetData, nil)
+			return
+		}
+
+		serve(c, files, key, map[string]string{
+			"Content-Disposition": `attachment; filename="` + path.Base(key) + `"`,
+			"Cache-Control":       "private, no-store",
+		})
+	}
+}
+
+// Upload stores the body of a PUT request sent to a signed upload URL of a
+// private storage. The signature covers the Content-Type and the
+// Content-Length headers, so the client can't send more than it was allowed.
+func Upload(files storage.Storage, verifier storage.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength < 0 {
+			utils.FullyResponse(c, 411, "Content-Length header is required", utils.ErrBadRequest, nil)
+			return
+		}
+
+		key := strings.TrimPrefix(c.Param("key"), "/")
+		contentType := c.GetHeader("Content-Type")
+		size := c.Request.ContentLength
+		if err := verifier.VerifyUpload(key, contentType, size, c.Query("expires"), c.Query("signature")); err != nil {
+			utils.FullyResponse(c, 403, "Upload link is invalid, expired or does not match the file", utils.ErrPermissionDenied, nil)
+			return
+		}
+
+		body := http.MaxBytesReader(c.Writer, c.Request.Body, size)
+		if err := files.Put(c.Request.Context(), key, body, size, contentType); err != nil {
+			utils.ServerErrorResponse(c, 500, "Error store file", utils.ErrSaveData, err)
+			return
+		}
+		utils.FullyResponse(c, 200, "File uploaded", nil, nil)
+	}
+}
+
+// Serve serves the objects of a public storage whose backend can't be served
+// by a static route, like the memory backend
+func Serve(files storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serve(c, files, strings.TrimPrefix(c.Param("key"), "/"), nil)
+	}
+}
+
+// serve streams the object with the extra headers
+func serve(c *gin.Context, files storage.Storage, key string, headers map[string]string) {
+	body, object, err := files.Get(c.Request.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		utils.FullyResponse(c, 404, "File not found", utils.ErrGetData, nil)
+		return
+	} else if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error read file", utils.ErrGetData, err)
+		return
+	}
+	defer body.Close()
+
+	c.DataFromReader(200, object.Size, object.ContentType, body, headers)
+}
@@ -0,0 +1,38 @@
+package health
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/pkg/config"
+	"github.com/yorukot/go-template/pkg/health"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+// LivenessResponse represents the liveness status
+type LivenessResponse struct {
+	Status string `json:"status"`
+}
+
+// Liveness reports that the process is up and serving requests
+func Liveness(c *gin.Context) {
+	utils.FullyResponse(c, 200, "Alive", nil, LivenessResponse{Status: health.StatusUp})
+}
+
+// Readiness checks every registered dependency and reports per-component status and latency
+func Readiness(c *gin.Context) {
+	report := health.Run(c.Request.Context(), config.Get().Health.CheckTimeout)
+
+	// Don't expose dependency error details in production
+	if gin.Mode() == gin.ReleaseMode {
+		for name, status := range report.Components {
+			status.Error = ""
+			report.Components[name] = status
+		}
+	}
+
+	if !report.Ready {
+		utils.FullyResponse(c, 503, "Service not ready", utils.ErrServiceNotReady, report)
+		return
+	}
+
+	utils.FullyResponse(c, 200, "Ready", nil, report)
+}
@@ -0,0 +1,262 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/app/models"
+	"github.com/yorukot/go-template/app/repository"
+	"github.com/yorukot/go-template/pkg/encryption"
+	"github.com/yorukot/go-template/pkg/logger"
+	"github.com/yorukot/go-template/pkg/metrics"
+	"github.com/yorukot/go-template/pkg/storage"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+// magicLength is the number of bytes read from an uploaded file to check its type
+const magicLength = 8
+
+// uploadExtensions maps the content types that can be uploaded to the
+// extension of their object key, the local backend serves a file with the
+// content type of its extension
+var uploadExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// Handler serves the upload endpoints
+type Handler struct {
+	uploads repository.UploadRepository
+	users   repository.UserRepository
+	files   storage.Storage
+}
+
+// NewHandler creates the upload handler, the files are uploaded to the given storage
+func NewHandler(uploads repository.UploadRepository, users repository.UserRepository, files storage.Storage) *Handler {
+	return &Handler{uploads: uploads, users: users, files: files}
+}
+
+// CreateUploadRequest represents the request body for a new upload
+type CreateUploadRequest struct {
+	ContentType string `json:"content_type" binding:"required,oneof=image/jpeg image/png image/gif"`
+	Size        int64  `json:"size" binding:"required,min=1"`
+}
+
+// CreateUploadResponse represents the response body for a new upload, the
+// client sends the file to URL with Method and Headers
+type CreateUploadResponse struct {
+	Upload  models.Upload     `json:"upload"`
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+}
+
+// CompleteUploadResponse represents the response body for a completed
+// upload, URL downloads the file until UPLOAD_URL_TTL has passed
+type CompleteUploadResponse struct {
+	Upload models.Upload `json:"upload"`
+	URL    string        `json:"url"`
+}
+
+// CreateUpload records a pending upload and returns a signed URL that stores
+// the file straight in the private storage, so its bytes never go through
+// the app on S3. The URL only accepts the declared content type and size.
+func (h *Handler) CreateUpload(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.FullyResponse(c, 403, "UserID not found in context", utils.ErrUserIDNotFound, nil)
+		return
+	}
+
+	var request CreateUploadRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+	if request.Size > utils.UploadMaxSize {
+		utils.FullyResponse(c, 413, "File is too large", utils.ErrFileTooLarge, nil)
+		return
+	}
+
+	user, err := h.users.GetByID(c.Request.Context(), userID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && user.DeletedAt != nil) {
+		utils.FullyResponse(c, 403, "User not found", utils.ErrGetData, nil)
+		return
+	} else if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error retrieving user data", utils.ErrGetData, err)
+		return
+	}
+	if user.DisabledAt != nil {
+		utils.FullyResponse(c, 403, "Account is disabled", utils.ErrAccountDisabled, nil)
+		return
+	}
+
+	id := encryption.GenerateID()
+	upload := models.Upload{
+		ID:          id,
+		UserID:      userID,
+		Status:      models.UploadPending,
+		ObjectKey:   "uploads/" + strconv.FormatUint(userID, 10) + "/" + strconv.FormatUint(id, 10) + uploadExtensions[request.ContentType],
+		ContentType: request.ContentType,
+		Size:        request.Size,
+		ExpiresAt:   time.Now().Add(utils.UploadURLTTL),
+	}
+	url, err := h.files.SignedUploadURL(c.Request.Context(), upload.ObjectKey, upload.ContentType, upload.Size, utils.UploadURLTTL)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error sign upload url", utils.ErrGenerateToken, err)
+		return
+	}
+	if err := h.uploads.Create(c.Request.Context(), &upload); err != nil {
+		utils.ServerErrorResponse(c, 500, "Error save upload", utils.ErrSaveData, err)
+		return
+	}
+
+	metrics.Uploads.WithLabelValues("requested").Inc()
+	utils.FullyResponse(c, 201, "Upload created", nil, CreateUploadResponse{
+		Upload: upload,
+		URL:    url,
+		Method: http.MethodPut,
+		Headers: map[string]string{
+			"Content-Type":   upload.ContentType,
+			"Content-Length": strconv.FormatInt(upload.Size, 10),
+		},
+	})
+}
+
+// CompleteUpload checks the file sent to the signed URL against the upload:
+// it must exist, have the declared size and start with the magic bytes of
+// the declared image type. A file that fails the checks is deleted with its
+// upload, completing an upload twice returns it again.
+func (h *Handler) CompleteUpload(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.FullyResponse(c, 403, "UserID not found in context", utils.ErrUserIDNotFound, nil)
+		return
+	}
+
+	upload, err := h.fetchUpload(c, userID)
+	if err != nil {
+		return // Error response sent in the fetch function
+	}
+	if upload.Status == models.UploadCompleted {
+		h.respondCompleted(c, upload)
+		return
+	}
+
+	ctx := c.Request.Context()
+	object, err := h.files.Stat(ctx, upload.ObjectKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		utils.FullyResponse(c, 409, "File has not been uploaded yet", utils.ErrUploadIncomplete, nil)
+		return
+	} else if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error retrieving uploaded file", utils.ErrGetData, err)
+		return
+	}
+	if object.Size != upload.Size {
+		h.reject(c, upload, "File size does not match the upload")
+		return
+	}
+
+	magic, err := h.readMagic(ctx, upload.ObjectKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		utils.FullyResponse(c, 409, "File has not been uploaded yet", utils.ErrUploadIncomplete, nil)
+		return
+	} else if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error read uploaded file", utils.ErrGetData, err)
+		return
+	}
+	if valid, mimeType, _ := utils.IsValidImageType(magic); !valid || mimeType != upload.ContentType {
+		h.reject(c, upload, "File is not a "+upload.ContentType+" image")
+		return
+	}
+
+	completed, err := h.uploads.Complete(ctx, &upload)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error save upload", utils.ErrSaveData, err)
+		return
+	}
+	if !completed {
+		// Completed by a concurrent request, or expired by the sweep meanwhile
+		if upload, err = h.fetchUpload(c, userID); err != nil {
+			return // Error response sent in the fetch function
+		}
+		if upload.Status != models.UploadCompleted {
+			utils.FullyResponse(c, 404, "Upload not found or expired", utils.ErrGetData, nil)
+			return
+		}
+	} else {
+		metrics.Uploads.WithLabelValues("completed").Inc()
+	}
+
+	h.respondCompleted(c, upload)
+}
+
+// fetchUpload gets the upload of the id path parameter, the uploads of other
+// users and the expired ones are not found
+func (h *Handler) fetchUpload(c *gin.Context, userID uint64) (models.Upload, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.FullyResponse(c, 404, "Upload not found or expired", utils.ErrGetData, nil)
+		return models.Upload{}, repository.ErrNotFound
+	}
+
+	upload, err := h.uploads.GetByID(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && (upload.UserID != userID || upload.Status == models.UploadExpired)) {
+		utils.FullyResponse(c, 404, "Upload not found or expired", utils.ErrGetData, nil)
+		return models.Upload{}, repository.ErrNotFound
+	} else if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error retrieving upload", utils.ErrGetData, err)
+		return models.Upload{}, err
+	}
+	return upload, nil
+}
+
+// readMagic reads the first bytes of the object, a shorter object returns all of them
+func (h *Handler) readMagic(ctx context.Context, key string) ([]byte, error) {
+	body, _, err := h.files.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	magic := make([]byte, magicLength)
+	n, err := io.ReadFull(body, magic)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return magic[:n], nil
+}
+
+// reject deletes the file and the upload that failed the checks. The upload
+// is marked expired first, so the sweep retries the deletion if it fails.
+func (h *Handler) reject(c *gin.Context, upload models.Upload, message string) {
+	ctx := c.Request.Context()
+	if expired, err := h.uploads.Expire(ctx, &upload); err != nil {
+		logger.Log.Sugar().Warnf("Failed to expire rejected upload %d: %v", upload.ID, err)
+	} else if expired {
+		if err := h.files.Delete(ctx, upload.ObjectKey); err != nil {
+			logger.Log.Sugar().Warnf("Failed to delete rejected upload %s: %v", upload.ObjectKey, err)
+		} else if err := h.uploads.Delete(ctx, upload.ID); err != nil {
+			logger.Log.Sugar().Warnf("Failed to delete rejected upload %d: %v", upload.ID, err)
+		}
+	}
+
+	metrics.Uploads.WithLabelValues("rejected").Inc()
+	utils.FullyResponse(c, 400, message, utils.ErrUploadMismatch, nil)
+}
+
+// respondCompleted sends the completed upload with a download link
+func (h *Handler) respondCompleted(c *gin.Context, upload models.Upload) {
+	url, err := h.files.SignedURL(c.Request.Context(), upload.ObjectKey, utils.UploadURLTTL)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error sign download url", utils.ErrGenerateToken, err)
+		return
+	}
+	utils.FullyResponse(c, 200, "Upload completed", nil, CompleteUploadResponse{Upload: upload, URL: url})
+}
@@ -0,0 +1,229 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/app/controllers/files"
+	"github.com/yorukot/go-template/app/models"
+	"github.com/yorukot/go-template/app/repository"
+	"github.com/yorukot/go-template/pkg/config"
+	"github.com/yorukot/go-template/pkg/encryption"
+	"github.com/yorukot/go-template/pkg/storage"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+// TestMain sets the upload limits read by the handlers
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		App:    config.AppConfig{BaseURL: "http://localhost:8080", Version: "1"},
+		Upload: config.UploadConfig{MaxSize: 1 << 20, URLTTL: 15 * time.Minute},
+	}
+	config.Set(cfg)
+	utils.Init(cfg)
+	os.Exit(m.Run())
+}
+
+// pngData is a file starting with the PNG magic bytes
+var pngData = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 56)...)
+
+// testEnv routes the upload endpoints and the signed upload route of a memory
+// storage, the requests are made as one user
+type testEnv struct {
+	router  *gin.Engine
+	uploads *repository.MemoryUploadRepository
+	files   *storage.Memory
+	user    models.User
+}
+
+// newTestEnv stores a user and routes the endpoints as that user
+func newTestEnv(t *testing.T) testEnv {
+	t.Helper()
+	users, uploads := repository.NewMemoryUserRepository(), repository.NewMemoryUploadRepository()
+	user := models.User{ID: encryption.GenerateID(), DisplayName: "alice", Email: "alice@example.com", Language: "en"}
+	if err := users.Create(context.Background(), &user); err != nil {
+		t.Fatal(err)
+	}
+	memory := storage.NewMemory("http://localhost:8080"+storage.DownloadRoute, "secret")
+
+	h := NewHandler(uploads, users, memory)
+	router := gin.New()
+	router.PUT(storage.DownloadRoute+"/*key", files.Upload(memory, memory))
+	authorized := router.Group("/uploads", func(c *gin.Context) { c.Set("userID", user.ID) })
+	authorized.POST("", h.CreateUpload)
+	authorized.POST("/:id/complete", h.CompleteUpload)
+	return testEnv{router: router, uploads: uploads, files: memory, user: user}
+}
+
+// response is the body written by utils.FullyResponse
+type response struct {
+	Message string          `json:"message"`
+	Error   string          `json:"error"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// do sends the request to the router and decodes the response
+func (env testEnv) do(t *testing.T, request *http.Request) (int, response) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	env.router.ServeHTTP(recorder, request)
+
+	var res response
+	if err := json.Unmarshal(recorder.Body.Bytes(), &res); err != nil {
+		t.Fatalf("invalid response body %q: %v", recorder.Body.String(), err)
+	}
+	return recorder.Code, res
+}
+
+// create requests an upload of the content type and size, the returned
+// upload is the stored one since the object key is not sent
+func (env testEnv) create(t *testing.T, contentType string, size int64) (int, response, CreateUploadResponse) {
+	t.Helper()
+	body := `{"content_type":"` + contentType + `","size":` + strconv.FormatInt(size, 10) + `}`
+	request := httptest.NewRequest(http.MethodPost, "/uploads", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	status, res := env.do(t, request)
+
+	var created CreateUploadResponse
+	if status == http.StatusCreated {
+		if err := json.Unmarshal(res.Result, &created); err != nil {
+			t.Fatalf("invalid upload %s: %v", res.Result, err)
+		}
+		upload, err := env.uploads.GetByID(context.Background(), created.Upload.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		created.Upload = upload
+	}
+	return status, res, created
+}
+
+// put sends the data to the signed URL with the content type
+func (env testEnv) put(t *testing.T, signedURL string, contentType string, data []byte) (int, response) {
+	t.Helper()
+	parsed, err := url.Parse(signedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := httptest.NewRequest(http.MethodPut, parsed.RequestURI(), bytes.NewReader(data))
+	request.Header.Set("Content-Type", contentType)
+	return env.do(t, request)
+}
+
+// complete completes the upload
+func (env testEnv) complete(t *testing.T, id uint64) (int, response) {
+	t.Helper()
+	request := httptest.NewRequest(http.MethodPost, "/uploads/"+strconv.FormatUint(id, 10)+"/complete", nil)
+	return env.do(t, request)
+}
+
+func TestUpload(t *testing.T) {
+	env := newTestEnv(t)
+
+	status, res, created := env.create(t, "image/png", int64(len(pngData)))
+	if status != http.StatusCreated {
+		t.Fatalf("got status %d %+v, want 201", status, res)
+	}
+	if created.Upload.Status != models.UploadPending || !strings.HasSuffix(created.Upload.ObjectKey, ".png") {
+		t.Fatalf("got upload %+v, want a pending .png upload", created.Upload)
+	}
+
+	status, res = env.complete(t, created.Upload.ID)
+	if status != http.StatusConflict || res.Error != utils.ErrUploadIncomplete {
+		t.Fatalf("got status %d %+v before the file was sent, want 409 %s", status, res, utils.ErrUploadIncomplete)
+	}
+
+	status, res = env.put(t, created.URL, "image/gif", pngData)
+	if status != http.StatusForbidden {
+		t.Fatalf("got status %d %+v for another content type, want 403", status, res)
+	}
+	status, res = env.put(t, created.URL, created.Headers["Content-Type"], pngData)
+	if status != http.StatusOK {
+		t.Fatalf("got status %d %+v for the signed upload, want 200", status, res)
+	}
+
+	for _, attempt := range []string{"first", "second"} {
+		status, res = env.complete(t, created.Upload.ID)
+		if status != http.StatusOK {
+			t.Fatalf("got status %d %+v for the %s completion, want 200", status, res, attempt)
+		}
+		var completed CompleteUploadResponse
+		if err := json.Unmarshal(res.Result, &completed); err != nil {
+			t.Fatalf("invalid upload %s: %v", res.Result, err)
+		}
+		if completed.Upload.Status != models.UploadCompleted || completed.URL == "" {
+			t.Errorf("got %+v for the %s completion, want a completed upload with a link", completed, attempt)
+		}
+	}
+
+	object, err := env.files.Stat(context.Background(), created.Upload.ObjectKey)
+	if err != nil || object.Size != int64(len(pngData)) || object.ContentType != "image/png" {
+		t.Errorf("got object %+v, %v, want the uploaded file", object, err)
+	}
+}
+
+func TestCreateUploadTooLarge(t *testing.T) {
+	env := newTestEnv(t)
+
+	status, res, _ := env.create(t, "image/png", utils.UploadMaxSize+1)
+	if status != http.StatusRequestEntityTooLarge || res.Error != utils.ErrFileTooLarge {
+		t.Errorf("got status %d %+v, want 413 %s", status, res, utils.ErrFileTooLarge)
+	}
+}
+
+func TestCompleteUploadRejects(t *testing.T) {
+	gifData := append([]byte("GIF89a"), bytes.Repeat([]byte{0}, len(pngData)-6)...)
+	tests := []struct {
+		name string
+		data []byte // stored at the key of a PNG upload of len(pngData) bytes
+	}{
+		{name: "size mismatch", data: pngData[:len(pngData)-1]},
+		{name: "type mismatch", data: gifData},
+		{name: "not an image", data: bytes.Repeat([]byte("x"), len(pngData))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			status, res, created := env.create(t, "image/png", int64(len(pngData)))
+			if status != http.StatusCreated {
+				t.Fatalf("got status %d %+v, want 201", status, res)
+			}
+
+			// Stored directly, the signed URL refuses a file that does not
+			// match its size, but a backend may not enforce it
+			ctx := context.Background()
+			if err := env.files.Put(ctx, created.Upload.ObjectKey, bytes.NewReader(tt.data), int64(len(tt.data)), "image/png"); err != nil {
+				t.Fatal(err)
+			}
+
+			status, res = env.complete(t, created.Upload.ID)
+			if status != http.StatusBadRequest || res.Error != utils.ErrUploadMismatch {
+				t.Fatalf("got status %d %+v, want 400 %s", status, res, utils.ErrUploadMismatch)
+			}
+			if _, err := env.files.Stat(ctx, created.Upload.ObjectKey); !errors.Is(err, storage.ErrNotFound) {
+				t.Errorf("got %v for the rejected file, want it deleted", err)
+			}
+			if _, err := env.uploads.GetByID(ctx, created.Upload.ID); !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("got %v for the rejected upload, want it deleted", err)
+			}
+
+			status, res = env.complete(t, created.Upload.ID)
+			if status != http.StatusNotFound {
+				t.Errorf("got status %d %+v completing the rejected upload again, want 404", status, res)
+			}
+		})
+	}
+}
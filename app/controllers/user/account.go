@@ -0,0 +1,87 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/app/models"
+	"github.com/yorukot/go-template/pkg/encryption"
+	"github.com/yorukot/go-template/pkg/metrics"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+// DeleteAccountRequest represents the request body for account deletion
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required,max=128"`
+}
+
+// DeleteAccountResponse tells when the deleted account is purged for good
+type DeleteAccountResponse struct {
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// DeleteAccount soft-deletes the account of the user and revokes every
+// session. Logging in before the grace period ends restores the account,
+// after that it is purged by the background job.
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	userID, err := extractUserIDFromContext(c)
+	if err != nil {
+		return // Error response sent in the extraction function
+	}
+
+	var request DeleteAccountRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	user, err := h.fetchUserByID(c, userID)
+	if err != nil {
+		return // Error response sent in the fetch function
+	}
+
+	if err := confirmPassword(c, user, request.Password); err != nil {
+		return // Error response sent in the confirm function
+	}
+
+	deletedAt := time.Now()
+	user.DeletedAt, user.UpdatedAt = &deletedAt, deletedAt
+	err = h.tx.WithTx(c.Request.Context(), func(ctx context.Context) error {
+		if err := h.users.MarkDeleted(ctx, &user); err != nil {
+			return err
+		}
+		_, err := h.sessions.DeleteByUserID(ctx, user.ID)
+		return err
+	})
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error delete account", utils.ErrDeleteData, err)
+		return
+	}
+
+	utils.SetCookie(c, "refresh_token", "", -1, true)
+	utils.SetCookie(c, "access_token", "", -1, false)
+
+	metrics.AccountDeletions.Inc()
+	utils.FullyResponse(c, 200, "Account deleted", nil, DeleteAccountResponse{
+		PurgeAt: deletedAt.Add(utils.AccountDeletionGracePeriod),
+	})
+}
+
+// confirmPassword verifies the password re-entered by the user. Accounts
+// without a password, created through OAuth, can't confirm one.
+func confirmPassword(c *gin.Context, user models.User, password string) error {
+	if user.Password == "" {
+		utils.FullyResponse(c, 400, "Account has no password", utils.ErrInvalidPassword, nil)
+		return errors.New("account has no password")
+	}
+
+	match, err := encryption.ComparePasswordAndHash(password, user.Password)
+	if err != nil || !match {
+		utils.FullyResponse(c, 400, "Invalid password", utils.ErrInvalidPassword, nil)
+		return errors.New("invalid password")
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"io"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/pkg/imaging"
+	"github.com/yorukot/go-template/pkg/logger"
+	"github.com/yorukot/go-template/pkg/storage"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+// multipartOverhead is the room left for the multipart headers and boundaries
+// on top of AVATAR_MAX_SIZE when the request body is limited
+const multipartOverhead = 64 << 10
+
+// UploadAvatar replaces the avatar of the user with the image of the
+// "avatar" multipart field. The image is decoded and re-encoded at every
+// storage.AvatarSizes, which drops its metadata, and the previous avatar is
+// deleted from the public storage.
+func (h *Handler) UploadAvatar(c *gin.Context) {
+	userID, err := extractUserIDFromContext(c)
+	if err != nil {
+		return // Error response sent in the extraction function
+	}
+
+	data, err := readAvatarUpload(c)
+	if err != nil {
+		return // Error response sent in the read function
+	}
+
+	if valid, _, _ := utils.IsValidImageType(data); !valid {
+		utils.FullyResponse(c, 400, "File is not a JPEG, PNG or GIF image", utils.ErrInvalidImage, nil)
+		return
+	}
+	img, _, err := imaging.Decode(data, utils.AvatarMaxDimension)
+	if errors.Is(err, imaging.ErrTooLarge) {
+		utils.FullyResponse(c, 400, "Image dimensions are too large", utils.ErrInvalidImage, nil)
+		return
+	} else if err != nil {
+		utils.FullyResponse(c, 400, "Invalid image", utils.ErrInvalidImage, nil)
+		return
+	}
+
+	user, err := h.fetchUserByID(c, userID)
+	if err != nil {
+		return // Error response sent in the fetch function
+	}
+
+	ctx := c.Request.Context()
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:16])
+	avatarURL, err := h.storeAvatar(ctx, userID, hash, img)
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error store avatar", utils.ErrSaveData, err)
+		return
+	}
+
+	previous := user.Avatar
+	user.Avatar, user.UpdatedAt = &avatarURL, time.Now()
+	if err := h.users.UpdateAvatar(ctx, &user); err != nil {
+		if previous == nil || *previous != avatarURL {
+			h.deleteAvatar(ctx, avatarURL)
+		}
+		utils.ServerErrorResponse(c, 500, "Error update user", utils.ErrSaveData, err)
+		return
+	}
+
+	// The same image uploaded again keeps its URL
+	if previous != nil && *previous != avatarURL {
+		h.deleteAvatar(ctx, *previous)
+	}
+
+	profile, err := createUserProfile(c, user)
+	if err != nil {
+		return // Error response sent in the create function
+	}
+
+	utils.FullyResponse(c, 200, "Avatar updated", nil, profile)
+}
+
+// readAvatarUpload reads the "avatar" multipart field, rejecting files larger
+// than AVATAR_MAX_SIZE before they are read in full
+func readAvatarUpload(c *gin.Context) ([]byte, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, utils.AvatarMaxSize+multipartOverhead)
+
+	file, header, err := c.Request.FormFile("avatar")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.FullyResponse(c, 413, "Avatar is too large", utils.ErrFileTooLarge, nil)
+			return nil, err
+		}
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, "avatar file is required")
+		return nil, err
+	}
+	defer file.Close()
+
+	if header.Size > utils.AvatarMaxSize {
+		utils.FullyResponse(c, 413, "Avatar is too large", utils.ErrFileTooLarge, nil)
+		return nil, errors.New("avatar is too large")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, utils.AvatarMaxSize+1))
+	if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error read avatar", utils.ErrParseData, err)
+		return nil, err
+	}
+	if int64(len(data)) > utils.AvatarMaxSize {
+		utils.FullyResponse(c, 413, "Avatar is too large", utils.ErrFileTooLarge, nil)
+		return nil, errors.New("avatar is too large")
+	}
+
+	return data, nil
+}
+
+// storeAvatar uploads every size of the avatar and returns the URL of the
+// largest. The sizes already uploaded are deleted when one fails.
+func (h *Handler) storeAvatar(ctx context.Context, userID uint64, hash string, img image.Image) (string, error) {
+	var avatarURL string
+	format := ""
+	for _, size := range storage.AvatarSizes {
+		square := imaging.Square(img, size)
+		if format == "" {
+			format = imaging.FormatFor(square) // Every size shares the format of the largest
+		}
+
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, square, format); err != nil {
+			return "", err
+		}
+
+		ext, contentType := "jpg", "image/jpeg"
+		if format == imaging.PNG {
+			ext, contentType = "png", "image/png"
+		}
+		key := storage.AvatarKey(userID, hash, size, ext)
+		if err := h.public.Put(ctx, key, bytes.NewReader(buf.Bytes()), int64(buf.Len()), contentType); err != nil {
+			if cleanupErr := storage.DeletePrefix(ctx, h.public, path.Dir(key)+"/"); cleanupErr != nil {
+				logger.Log.Sugar().Warnf("Failed to delete partial avatar upload %s: %v", path.Dir(key), cleanupErr)
+			}
+			return "", err
+		}
+		if avatarURL == "" {
+			avatarURL = h.public.URL(key)
+		}
+	}
+	return avatarURL, nil
+}
+
+// deleteAvatar deletes an avatar that is no longer used, a failure only
+// leaves unused objects behind so it is logged instead of failing the request
+func (h *Handler) deleteAvatar(ctx context.Context, avatarURL string) {
+	if err := storage.DeleteAvatar(ctx, h.public, avatarURL); err != nil {
+		logger.Log.Sugar().Warnf("Failed to delete avatar %s: %v", avatarURL, err)
+	}
+}
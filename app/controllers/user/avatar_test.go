@@ -0,0 +1,172 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/pkg/storage"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+// newAvatarEnv returns a test env whose handler stores avatars in memory
+func newAvatarEnv(t *testing.T) (testEnv, *storage.Memory) {
+	t.Helper()
+	env := newTestEnv(t)
+	memory := storage.NewMemory("http://localhost/static", "secret")
+	env.h.public = &storage.Public{Storage: memory, BaseURL: "http://localhost/static"}
+	return env, memory
+}
+
+// pngImage encodes a square PNG filled with the color
+func pngImage(t *testing.T, fill color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 300, 300))
+	for x := 0; x < 300; x++ {
+		for y := 0; y < 300; y++ {
+			img.Set(x, y, fill)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// uploadAvatar calls UploadAvatar as the user with the file as the "avatar" field
+func uploadAvatar(t *testing.T, env testEnv, file []byte) (int, response) {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("avatar", "avatar.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPut, "/", &body)
+	c.Request.Header.Set("Content-Type", form.FormDataContentType())
+	c.Set("userID", env.user.ID)
+	env.h.UploadAvatar(c)
+
+	var res response
+	if err := json.Unmarshal(recorder.Body.Bytes(), &res); err != nil {
+		t.Fatalf("invalid response body %q: %v", recorder.Body.String(), err)
+	}
+	return recorder.Code, res
+}
+
+// avatarKeys lists the stored avatar objects
+func avatarKeys(t *testing.T, memory *storage.Memory) []string {
+	t.Helper()
+	objects, err := memory.List(context.Background(), "avatars/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make([]string, 0, len(objects))
+	for _, object := range objects {
+		keys = append(keys, object.Key)
+	}
+	return keys
+}
+
+func TestUploadAvatarRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   []byte
+		status int
+		code   string
+	}{
+		{name: "too large", file: bytes.Repeat([]byte{0}, int(utils.AvatarMaxSize)+1), status: http.StatusRequestEntityTooLarge, code: utils.ErrFileTooLarge},
+		{name: "not an image", file: []byte("%PDF-1.7 not an image"), status: http.StatusBadRequest, code: utils.ErrInvalidImage},
+		{name: "truncated image", file: pngImage(t, color.White)[:64], status: http.StatusBadRequest, code: utils.ErrInvalidImage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, memory := newAvatarEnv(t)
+			status, res := uploadAvatar(t, env, tt.file)
+			if status != tt.status || res.Error != tt.code {
+				t.Fatalf("got status %d %+v, want %d %s", status, res, tt.status, tt.code)
+			}
+			if keys := avatarKeys(t, memory); len(keys) != 0 {
+				t.Errorf("got objects %v, want none stored", keys)
+			}
+			if user, _ := env.users.GetByID(context.Background(), env.user.ID); user.Avatar != nil {
+				t.Errorf("got avatar %s, want none", *user.Avatar)
+			}
+		})
+	}
+}
+
+func TestUploadAvatar(t *testing.T) {
+	env, memory := newAvatarEnv(t)
+
+	status, res := uploadAvatar(t, env, pngImage(t, color.White))
+	if status != http.StatusOK {
+		t.Fatalf("got status %d %+v, want 200", status, res)
+	}
+	first := res.profile(t).Avatar
+	if first == nil || !strings.HasSuffix(*first, "/256.jpg") {
+		t.Fatalf("got avatar %v, want the 256 pixel URL", first)
+	}
+	if keys := avatarKeys(t, memory); len(keys) != len(storage.AvatarSizes) {
+		t.Fatalf("got objects %v, want one per size", keys)
+	}
+
+	t.Run("same image keeps the avatar", func(t *testing.T) {
+		status, res := uploadAvatar(t, env, pngImage(t, color.White))
+		if status != http.StatusOK {
+			t.Fatalf("got status %d %+v, want 200", status, res)
+		}
+		if avatar := res.profile(t).Avatar; avatar == nil || *avatar != *first {
+			t.Errorf("got avatar %v, want %s", avatar, *first)
+		}
+		if keys := avatarKeys(t, memory); len(keys) != len(storage.AvatarSizes) {
+			t.Errorf("got objects %v, want the previous avatar kept", keys)
+		}
+	})
+
+	t.Run("new image replaces the avatar", func(t *testing.T) {
+		status, res := uploadAvatar(t, env, pngImage(t, color.Black))
+		if status != http.StatusOK {
+			t.Fatalf("got status %d %+v, want 200", status, res)
+		}
+		second := res.profile(t).Avatar
+		if second == nil || *second == *first {
+			t.Fatalf("got avatar %v, want a new URL", second)
+		}
+
+		keys := avatarKeys(t, memory)
+		if len(keys) != len(storage.AvatarSizes) {
+			t.Fatalf("got objects %v, want only the new avatar", keys)
+		}
+		key, _ := env.h.public.Key(*second)
+		for _, k := range keys {
+			if path.Dir(k) != path.Dir(key) {
+				t.Errorf("object %s of the previous avatar is left", k)
+			}
+		}
+		if user, _ := env.users.GetByID(context.Background(), env.user.ID); user.Avatar == nil || *user.Avatar != *second {
+			t.Errorf("got stored avatar %v, want %s", user.Avatar, *second)
+		}
+	})
+}
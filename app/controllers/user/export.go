@@ -0,0 +1,52 @@
+package user
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/app/models"
+	"github.com/yorukot/go-template/app/repository"
+	"github.com/yorukot/go-template/pkg/encryption"
+	"github.com/yorukot/go-template/pkg/metrics"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+// RequestExport queues an export of the personal data of the user, the
+// export job builds the archive and emails a download link. A user can
+// request one export per USER_EXPORT_INTERVAL, failed exports don't count.
+func (h *Handler) RequestExport(c *gin.Context) {
+	userID, err := extractUserIDFromContext(c)
+	if err != nil {
+		return // Error response sent in the extraction function
+	}
+
+	if _, err := h.fetchUserByID(c, userID); err != nil {
+		return // Error response sent in the fetch function
+	}
+
+	export := models.Export{
+		ID:     encryption.GenerateID(),
+		UserID: userID,
+		Status: models.ExportPending,
+	}
+	latest, created, err := h.exports.CreateUnlessRecent(c.Request.Context(), &export, time.Now().Add(-utils.UserExportInterval))
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.FullyResponse(c, 403, "User not found", utils.ErrGetData, nil)
+		return
+	} else if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error queue export", utils.ErrSaveData, err)
+		return
+	}
+	if !created {
+		wait := time.Until(latest.CreatedAt.Add(utils.UserExportInterval))
+		metrics.UserExports.WithLabelValues("throttled").Inc()
+		c.Header("Retry-After", strconv.Itoa(max(int(wait.Seconds()), 0)+1))
+		utils.FullyResponse(c, 429, "Export already requested recently", utils.ErrTooManyRequests, nil)
+		return
+	}
+
+	metrics.UserExports.WithLabelValues("requested").Inc()
+	utils.FullyResponse(c, 202, "Export queued, the download link will be emailed", nil, export)
+}
@@ -0,0 +1,20 @@
+package user
+
+import (
+	"github.com/yorukot/go-template/app/repository"
+	"github.com/yorukot/go-template/pkg/storage"
+)
+
+// Handler serves the user endpoints
+type Handler struct {
+	tx       repository.Transactor
+	users    repository.UserRepository
+	sessions repository.SessionRepository
+	exports  repository.ExportRepository
+	public   *storage.Public
+}
+
+// NewHandler creates the user handler
+func NewHandler(tx repository.Transactor, users repository.UserRepository, sessions repository.SessionRepository, exports repository.ExportRepository, public *storage.Public) *Handler {
+	return &Handler{tx: tx, users: users, sessions: sessions, exports: exports, public: public}
+}
@@ -7,9 +7,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
 	"github.com/yorukot/go-template/app/models"
-	"github.com/yorukot/go-template/app/queries"
+	"github.com/yorukot/go-template/app/repository"
 	"github.com/yorukot/go-template/pkg/utils"
-	"gorm.io/gorm"
 )
 
 // UserProfile represents a user's public profile without sensitive information
@@ -18,18 +17,19 @@ type UserProfile struct {
 	Avatar      *string   `json:"avatar,omitempty"`
 	DisplayName string    `json:"display_name"`
 	Email       string    `json:"email"`
+	Language    string    `json:"language"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 // GetProfile retrieves and returns the user's profile information
-func GetProfile(c *gin.Context) {
+func (h *Handler) GetProfile(c *gin.Context) {
 	userID, err := extractUserIDFromContext(c)
 	if err != nil {
 		return // Error response sent in the extraction function
 	}
 
-	user, err := fetchUserByID(c, userID)
+	user, err := h.fetchUserByID(c, userID)
 	if err != nil {
 		return // Error response sent in the fetch function
 	}
@@ -42,6 +42,63 @@ func GetProfile(c *gin.Context) {
 	utils.FullyResponse(c, 200, "User profile acquired", nil, profile)
 }
 
+// UpdateProfileRequest represents the request body for a profile update,
+// omitted fields are left unchanged
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name" binding:"omitempty,max=32,min=1,alphanumunicode"`
+	Language    *string `json:"language" binding:"omitempty,lang"`
+}
+
+// UpdateProfile changes the fields of the profile given in the request and
+// returns the updated profile
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	userID, err := extractUserIDFromContext(c)
+	if err != nil {
+		return // Error response sent in the extraction function
+	}
+
+	var request UpdateProfileRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.FullyResponse(c, 400, "Invalid request", utils.ErrBadRequest, err.Error())
+		return
+	}
+
+	user, err := h.fetchUserByID(c, userID)
+	if err != nil {
+		return // Error response sent in the fetch function
+	}
+
+	if applyProfileUpdate(&user, request) {
+		user.UpdatedAt = time.Now()
+		if err := h.users.UpdateProfile(c.Request.Context(), &user); err != nil {
+			utils.ServerErrorResponse(c, 500, "Error update user", utils.ErrSaveData, err)
+			return
+		}
+	}
+
+	profile, err := createUserProfile(c, user)
+	if err != nil {
+		return // Error response sent in the create function
+	}
+
+	utils.FullyResponse(c, 200, "User profile updated", nil, profile)
+}
+
+// applyProfileUpdate copies the fields given in the request to the user and
+// reports whether any of them changed
+func applyProfileUpdate(user *models.User, request UpdateProfileRequest) bool {
+	changed := false
+	if request.DisplayName != nil && *request.DisplayName != user.DisplayName {
+		user.DisplayName = *request.DisplayName
+		changed = true
+	}
+	if request.Language != nil && *request.Language != user.Language {
+		user.Language = *request.Language
+		changed = true
+	}
+	return changed
+}
+
 // extractUserIDFromContext gets the user ID from the Gin context
 func extractUserIDFromContext(c *gin.Context) (uint64, error) {
 	jwtContextID, exists := c.Get("userID")
@@ -53,15 +110,22 @@ func extractUserIDFromContext(c *gin.Context) (uint64, error) {
 	return jwtContextID.(uint64), nil
 }
 
-// fetchUserByID retrieves user information from the database using the user ID
-func fetchUserByID(c *gin.Context, userID uint64) (models.User, error) {
-	user, result := queries.GetUserQueueByID(userID)
-	if result.Error == gorm.ErrRecordNotFound {
+// fetchUserByID retrieves user information from the database using the user
+// ID, a deleted account is not found and a disabled account is refused even
+// while its access token is valid
+func (h *Handler) fetchUserByID(c *gin.Context, userID uint64) (models.User, error) {
+	user, err := h.users.GetByID(c.Request.Context(), userID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && user.DeletedAt != nil) {
 		utils.FullyResponse(c, 403, "User not found", utils.ErrGetData, nil)
-		return models.User{}, result.Error
-	} else if result.Error != nil {
-		utils.ServerErrorResponse(c, 500, "Error retrieving user data", utils.ErrGetData, result.Error)
-		return models.User{}, result.Error
+		return models.User{}, repository.ErrNotFound
+	} else if err != nil {
+		utils.ServerErrorResponse(c, 500, "Error retrieving user data", utils.ErrGetData, err)
+		return models.User{}, err
+	}
+
+	if user.DisabledAt != nil {
+		utils.FullyResponse(c, 403, "Account is disabled", utils.ErrAccountDisabled, nil)
+		return models.User{}, errors.New("account is disabled")
 	}
 
 	return user, nil
@@ -0,0 +1,225 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/app/models"
+	"github.com/yorukot/go-template/app/repository"
+	"github.com/yorukot/go-template/pkg/config"
+	"github.com/yorukot/go-template/pkg/encryption"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+// TestMain sets the settings read by the handlers, with cheap password hashing
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		App:     config.AppConfig{BaseURL: "http://localhost:8080", Version: "1"},
+		Argon2:  config.Argon2Config{Memory: 64, Iterations: 1, Parallelism: 1},
+		Cookie:  config.CookieConfig{Path: "/", RefreshTokenExpires: 60, AccessTokenExpires: 15},
+		Account: config.AccountConfig{DeletionGracePeriod: 30 * 24 * time.Hour},
+		Avatar:  config.AvatarConfig{MaxSize: 1 << 20, MaxDimension: 4096},
+	}
+	config.Set(cfg)
+	utils.Init(cfg)
+	os.Exit(m.Run())
+}
+
+// testEnv is a handler on memory repositories with one user
+type testEnv struct {
+	h        *Handler
+	users    *repository.MemoryUserRepository
+	sessions *repository.MemorySessionRepository
+	user     models.User
+}
+
+// newTestEnv stores a user with the password "password123" and a session
+func newTestEnv(t *testing.T) testEnv {
+	t.Helper()
+	users, sessions := repository.NewMemoryUserRepository(), repository.NewMemorySessionRepository()
+	hash, err := encryption.HashPassword("password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := models.User{ID: encryption.GenerateID(), DisplayName: "alice", Email: "alice@example.com", Password: hash, Language: "en"}
+	if err := users.Create(context.Background(), &user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := utils.CreateUserSession(context.Background(), sessions, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHandler(repository.MemoryTransactor{}, users, sessions, repository.NewMemoryExportRepository(), nil)
+	return testEnv{h: h, users: users, sessions: sessions, user: user}
+}
+
+// response is the body written by utils.FullyResponse
+type response struct {
+	Message string          `json:"message"`
+	Error   string          `json:"error"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// profile decodes the profile returned in the response
+func (r response) profile(t *testing.T) UserProfile {
+	t.Helper()
+	var profile UserProfile
+	if err := json.Unmarshal(r.Result, &profile); err != nil {
+		t.Fatalf("invalid profile %s: %v", r.Result, err)
+	}
+	return profile
+}
+
+// serve calls the handler as the user with the JSON body and decodes the response
+func serve(t *testing.T, handler gin.HandlerFunc, userID uint64, method string, body string) (int, response) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(method, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", userID)
+	handler(c)
+
+	var res response
+	if err := json.Unmarshal(recorder.Body.Bytes(), &res); err != nil {
+		t.Fatalf("invalid response body %q: %v", recorder.Body.String(), err)
+	}
+	return recorder.Code, res
+}
+
+func TestGetProfile(t *testing.T) {
+	env := newTestEnv(t)
+
+	status, res := serve(t, env.h.GetProfile, env.user.ID, http.MethodGet, "")
+	if status != http.StatusOK {
+		t.Fatalf("got status %d %+v, want 200", status, res)
+	}
+	if profile := res.profile(t); profile.ID != env.user.ID || profile.Email != env.user.Email {
+		t.Errorf("got profile %+v, want the profile of %d", profile, env.user.ID)
+	}
+
+	status, res = serve(t, env.h.GetProfile, encryption.GenerateID(), http.MethodGet, "")
+	if status != http.StatusForbidden {
+		t.Errorf("got status %d %+v for an unknown user, want 403", status, res)
+	}
+
+	disabledAt := time.Now()
+	env.user.DisabledAt = &disabledAt
+	if err := env.users.Update(context.Background(), &env.user); err != nil {
+		t.Fatal(err)
+	}
+	status, res = serve(t, env.h.GetProfile, env.user.ID, http.MethodGet, "")
+	if status != http.StatusForbidden || res.Error != utils.ErrAccountDisabled {
+		t.Errorf("got status %d %+v for a disabled user, want 403 %s", status, res, utils.ErrAccountDisabled)
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		status      int
+		displayName string
+		language    string
+	}{
+		{name: "display name only", body: `{"display_name":"bob"}`, status: http.StatusOK, displayName: "bob", language: "en"},
+		{name: "language only", body: `{"language":"zh-tw"}`, status: http.StatusOK, displayName: "alice", language: "zh-tw"},
+		{name: "no field", body: `{}`, status: http.StatusOK, displayName: "alice", language: "en"},
+		{name: "unknown language", body: `{"language":"xx"}`, status: http.StatusBadRequest, displayName: "alice", language: "en"},
+		{name: "empty display name", body: `{"display_name":""}`, status: http.StatusBadRequest, displayName: "alice", language: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			status, res := serve(t, env.h.UpdateProfile, env.user.ID, http.MethodPatch, tt.body)
+			if status != tt.status {
+				t.Fatalf("got status %d %+v, want %d", status, res, tt.status)
+			}
+
+			stored, err := env.users.GetByID(context.Background(), env.user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.DisplayName != tt.displayName || stored.Language != tt.language {
+				t.Errorf("got %q in %q, want %q in %q", stored.DisplayName, stored.Language, tt.displayName, tt.language)
+			}
+			if status != http.StatusOK {
+				return
+			}
+			if profile := res.profile(t); profile.DisplayName != tt.displayName || profile.Language != tt.language {
+				t.Errorf("got profile %+v, want %q in %q", profile, tt.displayName, tt.language)
+			}
+		})
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	t.Run("wrong password", func(t *testing.T) {
+		env := newTestEnv(t)
+		status, res := serve(t, env.h.DeleteAccount, env.user.ID, http.MethodDelete, `{"password":"wrong password"}`)
+		if status != http.StatusBadRequest || res.Error != utils.ErrInvalidPassword {
+			t.Fatalf("got status %d %+v, want 400 %s", status, res, utils.ErrInvalidPassword)
+		}
+		if stored, _ := env.users.GetByID(context.Background(), env.user.ID); stored.DeletedAt != nil {
+			t.Error("account deleted with a wrong password")
+		}
+	})
+
+	t.Run("soft-deletes the account and revokes the sessions", func(t *testing.T) {
+		env := newTestEnv(t)
+		status, res := serve(t, env.h.DeleteAccount, env.user.ID, http.MethodDelete, `{"password":"password123"}`)
+		if status != http.StatusOK {
+			t.Fatalf("got status %d %+v, want 200", status, res)
+		}
+
+		stored, err := env.users.GetByID(context.Background(), env.user.ID)
+		if err != nil || stored.DeletedAt == nil {
+			t.Fatalf("got %+v, %v, want a soft-deleted user", stored, err)
+		}
+		if list, _ := env.sessions.ListByUserID(context.Background(), env.user.ID); len(list) != 0 {
+			t.Errorf("got %d sessions, want none", len(list))
+		}
+
+		// The access token stays valid until it expires, the account is gone anyway
+		status, _ = serve(t, env.h.GetProfile, env.user.ID, http.MethodGet, "")
+		if status != http.StatusForbidden {
+			t.Errorf("got status %d for the profile of a deleted account, want 403", status)
+		}
+	})
+}
+
+func TestRequestExport(t *testing.T) {
+	utils.UserExportInterval = 24 * time.Hour
+	env := newTestEnv(t)
+
+	status, res := serve(t, env.h.RequestExport, env.user.ID, http.MethodPost, "")
+	if status != http.StatusAccepted {
+		t.Fatalf("got status %d %+v, want 202", status, res)
+	}
+	status, res = serve(t, env.h.RequestExport, env.user.ID, http.MethodPost, "")
+	if status != http.StatusTooManyRequests || res.Error != utils.ErrTooManyRequests {
+		t.Fatalf("got status %d %+v for a second export, want 429 %s", status, res, utils.ErrTooManyRequests)
+	}
+
+	// A failed export does not count
+	latest, err := env.h.exports.LatestByUserID(context.Background(), env.user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest.Status = models.ExportFailed
+	if err := env.h.exports.Update(context.Background(), &latest); err != nil {
+		t.Fatal(err)
+	}
+	if status, res = serve(t, env.h.RequestExport, env.user.ID, http.MethodPost, ""); status != http.StatusAccepted {
+		t.Errorf("got status %d %+v after a failed export, want 202", status, res)
+	}
+}
@@ -0,0 +1,282 @@
+package jobs
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/yorukot/go-template/app/models"
+	"github.com/yorukot/go-template/app/repository"
+	"github.com/yorukot/go-template/pkg/config"
+	"github.com/yorukot/go-template/pkg/logger"
+	"github.com/yorukot/go-template/pkg/metrics"
+	"github.com/yorukot/go-template/pkg/storage"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+const (
+	// exportBatchSize is the number of exports loaded at once by a run
+	exportBatchSize = 20
+	// exportClaimTimeout is how long an export stays claimed before another
+	// worker may retry it, in case the one processing it died
+	exportClaimTimeout = 10 * time.Minute
+)
+
+// exportNotice is the README of the archive, the app does not keep linked
+// identities or audit events so the archive says so instead of leaving them out silently
+const exportNotice = `This archive holds the personal data stored about your account.
+
+profile.json   your account profile
+sessions.json  the devices signed in to your account
+
+This service does not store linked identities or audit events, so the archive
+has none to include.
+`
+
+// exportEmail is the body of the email that carries the download link
+var exportEmail = template.Must(template.New("export").Parse(`<p>Hello {{.DisplayName}},</p>
+<p>The export of your personal data is ready, you can download it until {{.ExpiresAt.UTC.Format "2006-01-02 15:04 MST"}}:</p>
+<p><a href="{{.URL}}">Download your data</a></p>
+<p>If you did not request this export, please change your password.</p>`))
+
+// Exporter builds the personal data exports requested through POST /user/export
+type Exporter struct {
+	Exports  repository.ExportRepository
+	Users    repository.UserRepository
+	Sessions repository.SessionRepository
+	Storage  storage.Storage
+	Config   config.ExportConfig
+}
+
+// exportProfile is the profile of the archive, without the password hash
+type exportProfile struct {
+	ID          uint64     `json:"id,string"`
+	Email       string     `json:"email"`
+	DisplayName string     `json:"display_name"`
+	Avatar      *string    `json:"avatar,omitempty"`
+	IsAdmin     bool       `json:"is_admin"`
+	Language    string     `json:"language"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// exportSession is a session of the archive, without the refresh token
+type exportSession struct {
+	SessionID uint64    `json:"session_id,string"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Run expires the archives whose link is over, then builds every pending
+// export. A failed export is marked failed and the run goes on with the next.
+func (e *Exporter) Run(ctx context.Context) (int, error) {
+	if err := e.expire(ctx); err != nil {
+		return 0, err
+	}
+
+	built := 0
+	for {
+		exports, err := e.Exports.ListClaimable(ctx, time.Now().Add(-exportClaimTimeout), exportBatchSize)
+		if err != nil {
+			return built, fmt.Errorf("failed to list pending exports: %w", err)
+		}
+		for _, export := range exports {
+			claimed, err := e.Exports.Claim(ctx, &export, time.Now().Add(-exportClaimTimeout))
+			if err != nil {
+				return built, fmt.Errorf("failed to claim export %d: %w", export.ID, err)
+			}
+			if !claimed {
+				continue // Claimed by another instance
+			}
+
+			if err := e.build(ctx, &export); err != nil {
+				logger.Log.Sugar().Warnf("Failed to build export %d: %v", export.ID, err)
+				if err := e.fail(ctx, &export, err); err != nil {
+					return built, err
+				}
+				metrics.UserExports.WithLabelValues("failed").Inc()
+				continue
+			}
+			metrics.UserExports.WithLabelValues("completed").Inc()
+			built++
+		}
+		if len(exports) < exportBatchSize {
+			return built, nil
+		}
+	}
+}
+
+// Job returns the export as a background job that logs what it built
+func (e *Exporter) Job() Job {
+	return func(ctx context.Context) error {
+		built, err := e.Run(ctx)
+		if built > 0 {
+			logger.Log.Sugar().Infof("Built %d data exports", built)
+		}
+		return err
+	}
+}
+
+// build writes the archive of the export, stores it, saves the export as
+// completed and emails the link. A failed email fails the export, whose
+// archive is then deleted, so the user can request another one.
+func (e *Exporter) build(ctx context.Context, export *models.Export) error {
+	user, err := e.Users.GetByID(ctx, export.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.DeletedAt != nil {
+		return errors.New("account is deleted")
+	}
+	sessions, err := e.Sessions.ListByUserID(ctx, export.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	archive, err := buildArchive(user, sessions)
+	if err != nil {
+		return fmt.Errorf("failed to build archive: %w", err)
+	}
+
+	key := fmt.Sprintf("exports/%d/%d.zip", export.UserID, export.ID)
+	if err := e.Storage.Put(ctx, key, bytes.NewReader(archive), int64(len(archive)), "application/zip"); err != nil {
+		return fmt.Errorf("failed to store archive: %w", err)
+	}
+	export.ObjectKey = key
+
+	url, err := e.Storage.SignedURL(ctx, key, e.Config.LinkTTL)
+	if err != nil {
+		return fmt.Errorf("failed to sign download link: %w", err)
+	}
+	expiresAt := time.Now().Add(e.Config.LinkTTL)
+
+	completedAt := time.Now()
+	export.Status = models.ExportCompleted
+	export.CompletedAt, export.ExpiresAt = &completedAt, &expiresAt
+	if err := e.Exports.Update(ctx, export); err != nil {
+		return fmt.Errorf("failed to save export: %w", err)
+	}
+
+	var body bytes.Buffer
+	err = exportEmail.Execute(&body, map[string]any{
+		"DisplayName": user.DisplayName,
+		"URL":         url,
+		"ExpiresAt":   expiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render email: %w", err)
+	}
+	return utils.SendEmail(user.Email, "Your data export is ready", body.String())
+}
+
+// fail deletes whatever archive the export stored and marks it failed, so the
+// user can request a new one right away
+func (e *Exporter) fail(ctx context.Context, export *models.Export, cause error) error {
+	if export.ObjectKey != "" {
+		if err := e.Storage.Delete(ctx, export.ObjectKey); err != nil {
+			return fmt.Errorf("failed to delete archive of export %d: %w", export.ID, err)
+		}
+		export.ObjectKey = ""
+	}
+
+	message := cause.Error()
+	if len(message) > 512 {
+		message = message[:512]
+	}
+	export.Status, export.Error = models.ExportFailed, message
+	if err := e.Exports.Update(ctx, export); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to save export %d: %w", export.ID, err)
+	}
+	return nil
+}
+
+// expire deletes the archives whose download link is over
+func (e *Exporter) expire(ctx context.Context) error {
+	for {
+		exports, err := e.Exports.ListExpired(ctx, time.Now(), exportBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list expired exports: %w", err)
+		}
+		for _, export := range exports {
+			if err := e.Storage.Delete(ctx, export.ObjectKey); err != nil {
+				return fmt.Errorf("failed to delete archive of export %d: %w", export.ID, err)
+			}
+			export.Status, export.ObjectKey = models.ExportExpired, ""
+			if err := e.Exports.Update(ctx, &export); err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("failed to save export %d: %w", export.ID, err)
+			}
+		}
+		if len(exports) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+// buildArchive zips the JSON files of the export
+func buildArchive(user models.User, sessions []models.Session) ([]byte, error) {
+	profile := exportProfile{
+		ID:          user.ID,
+		Email:       user.Email,
+		DisplayName: user.DisplayName,
+		Avatar:      user.Avatar,
+		IsAdmin:     user.IsAdmin,
+		Language:    user.Language,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		DeletedAt:   user.DeletedAt,
+	}
+	exported := make([]exportSession, 0, len(sessions))
+	for _, session := range sessions {
+		exported = append(exported, exportSession{
+			SessionID: session.SessionID,
+			UserAgent: session.UserAgent,
+			CreatedAt: session.CreatedAt,
+			ExpiresAt: session.ExpiresAt,
+		})
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	create := func(name string) (io.Writer, error) {
+		return archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	}
+	files := []struct {
+		name    string
+		content any
+	}{
+		{"profile.json", profile},
+		{"sessions.json", exported},
+	}
+	for _, file := range files {
+		w, err := create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.content); err != nil {
+			return nil, err
+		}
+	}
+
+	w, err := create("README.txt")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write([]byte(exportNotice)); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/yorukot/go-template/app/models"
+	"github.com/yorukot/go-template/app/repository"
+	"github.com/yorukot/go-template/pkg/config"
+	"github.com/yorukot/go-template/pkg/storage"
+)
+
+// TestMain sets a config whose SMTP server refuses connections, so sending an email fails
+func TestMain(m *testing.M) {
+	config.Set(&config.Config{SMTP: config.SMTPConfig{Host: "127.0.0.1", Port: 1}})
+	os.Exit(m.Run())
+}
+
+func TestExporterFailures(t *testing.T) {
+	deletedAt := time.Now()
+	tests := []struct {
+		name string
+		user models.User
+	}{
+		{name: "deleted account", user: models.User{ID: 1, Email: "alice@example.com", DeletedAt: &deletedAt}},
+		{name: "email not sent", user: models.User{ID: 1, Email: "alice@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			private := storage.NewMemory("http://localhost/files", "secret")
+			e := &Exporter{
+				Exports:  repository.NewMemoryExportRepository(),
+				Users:    repository.NewMemoryUserRepository(),
+				Sessions: repository.NewMemorySessionRepository(),
+				Storage:  private,
+				Config:   config.ExportConfig{LinkTTL: time.Hour},
+			}
+			if err := e.Users.Create(ctx, &tt.user); err != nil {
+				t.Fatal(err)
+			}
+			if err := e.Exports.Create(ctx, &models.Export{ID: 10, UserID: tt.user.ID, Status: models.ExportPending}); err != nil {
+				t.Fatal(err)
+			}
+
+			built, err := e.Run(ctx)
+			if err != nil || built != 0 {
+				t.Fatalf("got %d built, %v, want 0", built, err)
+			}
+
+			// Failed exports are not returned, so the user may request another one
+			if export, err := e.Exports.LatestByUserID(ctx, tt.user.ID); !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("got export %+v, %v, want it failed", export, err)
+			}
+			if objects, _ := private.List(ctx, "exports/"); len(objects) != 0 {
+				t.Errorf("got archives %+v, want none", objects)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Package jobs holds the background work of the server. Every instance runs
+// every job, so a job must be safe to run on several instances at once.
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/yorukot/go-template/pkg/logger"
+	"github.com/yorukot/go-template/pkg/metrics"
+	"github.com/yorukot/go-template/pkg/shutdown"
+	"go.uber.org/zap"
+)
+
+// Job is one run of a background job, it must return once ctx is done
+type Job func(ctx context.Context) error
+
+// Every runs the job now and then every interval until shutdown. A failed
+// run is logged and the job runs again at the next tick. The shutdown hook
+// cancels the running job and waits for it to return.
+func Every(name string, interval time.Duration, job Job) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			run(ctx, name, job)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	shutdown.Register(shutdown.PhaseWorkers, name, func(shutdownCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-shutdownCtx.Done():
+			return shutdownCtx.Err()
+		}
+	})
+}
+
+// run runs the job once, recording its result and recovering its panics
+func run(ctx context.Context, name string, job Job) {
+	start := time.Now()
+	result := "success"
+	defer func() {
+		if r := recover(); r != nil {
+			result = "error"
+			logger.Log.Error("Job panicked", zap.String("job", name), zap.Any("panic", r))
+		}
+		metrics.JobRuns.WithLabelValues(name, result).Inc()
+		metrics.JobDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+	}()
+
+	if err := job(ctx); err != nil {
+		if ctx.Err() != nil {
+			result = "canceled"
+			return
+		}
+		result = "error"
+		logger.Log.Error("Job failed", zap.String("job", name), zap.Error(err))
+	}
+}
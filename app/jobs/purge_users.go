@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yorukot/go-template/app/models"
+	"github.com/yorukot/go-template/app/repository"
+	"github.com/yorukot/go-template/pkg/config"
+	"github.com/yorukot/go-template/pkg/logger"
+	"github.com/yorukot/go-template/pkg/metrics"
+	"github.com/yorukot/go-template/pkg/storage"
+)
+
+// purgeBatchSize is the number of accounts loaded at once by a purge
+const purgeBatchSize = 100
+
+// UserPurger removes the accounts whose deletion grace period is over
+type UserPurger struct {
+	Tx       repository.Transactor
+	Users    repository.UserRepository
+	Sessions repository.SessionRepository
+	Public   *storage.Public
+	Private  storage.Storage
+	Config   config.AccountConfig
+}
+
+// Run purges every account deleted longer than ACCOUNT_DELETION_GRACE_PERIOD
+// ago and returns how many were purged. It stops at the first account that
+// fails, which is retried by the next run.
+func (p *UserPurger) Run(ctx context.Context) (int, error) {
+	before := time.Now().Add(-p.Config.DeletionGracePeriod)
+
+	purged := 0
+	for {
+		users, err := p.Users.ListDeletedBefore(ctx, before, purgeBatchSize)
+		if err != nil {
+			return purged, fmt.Errorf("failed to list deleted users: %w", err)
+		}
+		for _, user := range users {
+			deleted, err := p.purge(ctx, user, before)
+			if err != nil {
+				return purged, fmt.Errorf("failed to purge user %d: %w", user.ID, err)
+			}
+			if deleted {
+				metrics.AccountPurges.Inc()
+				purged++
+			}
+		}
+		if len(users) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// Job returns the purge as a background job that logs what it purged
+func (p *UserPurger) Job() Job {
+	return func(ctx context.Context) error {
+		purged, err := p.Run(ctx)
+		if purged > 0 {
+			logger.Log.Sugar().Infof("Purged %d deleted accounts", purged)
+		}
+		return err
+	}
+}
+
+// purge deletes the user with its sessions, then its avatar, export archives
+// and uploads, and reports whether the user was deleted. The user row is
+// deleted first, only if it is still deleted past the grace period, which
+// locks it until the commit so a concurrent restore waits and then finds
+// nothing to restore. A failure to delete the files rolls the row back and is
+// retried instead of leaving objects nobody owns.
+func (p *UserPurger) purge(ctx context.Context, user models.User, before time.Time) (bool, error) {
+	deleted := false
+	err := p.Tx.WithTx(ctx, func(ctx context.Context) error {
+		// The user may have logged in and restored the account since it was
+		// listed, or have been purged by another instance. Deleted accounts
+		// can't change their avatar, so the listed one is still current.
+		err := p.Users.DeleteIfDeletedBefore(ctx, user.ID, before)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if _, err := p.Sessions.DeleteByUserID(ctx, user.ID); err != nil {
+			return err
+		}
+
+		if user.Avatar != nil {
+			if err := storage.DeleteAvatar(ctx, p.Public, *user.Avatar); err != nil {
+				return fmt.Errorf("failed to delete avatar: %w", err)
+			}
+		}
+		if err := storage.DeletePrefix(ctx, p.Private, fmt.Sprintf("exports/%d/", user.ID)); err != nil {
+			return fmt.Errorf("failed to delete export archives: %w", err)
+		}
+		if err := storage.DeletePrefix(ctx, p.Private, fmt.Sprintf("uploads/%d/", user.ID)); err != nil {
+			return fmt.Errorf("failed to delete uploads: %w", err)
+		}
+		if p.Config.ReserveDeletedEmails {
+			if err := p.Users.ReserveEmail(ctx, user.Email); err != nil {
+				return err
+			}
+		}
+
+		deleted = true
+		return nil
+	})
+	return deleted && err == nil, err
+}
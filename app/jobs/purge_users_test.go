@@ -0,0 +1,117 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yorukot/go-template/app/models"
+	"github.com/yorukot/go-template/app/repository"
+	"github.com/yorukot/go-template/pkg/config"
+	"github.com/yorukot/go-template/pkg/storage"
+)
+
+func TestUserPurger(t *testing.T) {
+	ctx := context.Background()
+	public := &storage.Public{Storage: storage.NewMemory("http://localhost/static", "secret"), BaseURL: "http://localhost/static"}
+	private := storage.NewMemory("http://localhost/files", "secret")
+	p := &UserPurger{
+		Tx:       repository.MemoryTransactor{},
+		Users:    repository.NewMemoryUserRepository(),
+		Sessions: repository.NewMemorySessionRepository(),
+		Public:   public,
+		Private:  private,
+		Config:   config.AccountConfig{DeletionGracePeriod: 24 * time.Hour},
+	}
+
+	// One account past the grace period and one still in it, each with files
+	expired, recent := time.Now().Add(-48*time.Hour), time.Now().Add(-time.Hour)
+	for _, user := range []models.User{
+		{ID: 1, Email: "purged@example.com", DeletedAt: &expired},
+		{ID: 2, Email: "kept@example.com", DeletedAt: &recent},
+	} {
+		avatar := public.URL(storage.AvatarKey(user.ID, "hash", 256, "png"))
+		user.Avatar = &avatar
+		if err := p.Users.Create(ctx, &user); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{
+			storage.AvatarKey(user.ID, "hash", 256, "png"),
+			storage.AvatarKey(user.ID, "hash", 64, "png"),
+		} {
+			if err := public.Put(ctx, key, strings.NewReader("png"), 3, "image/png"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, key := range []string{fmt.Sprintf("exports/%d/10.zip", user.ID), fmt.Sprintf("uploads/%d/20.png", user.ID)} {
+			if err := private.Put(ctx, key, strings.NewReader("data"), 4, "application/octet-stream"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	purged, err := p.Run(ctx)
+	if err != nil || purged != 1 {
+		t.Fatalf("got %d purged, %v, want 1", purged, err)
+	}
+	if _, err := p.Users.GetByID(ctx, 1); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("got %v for the purged user, want ErrNotFound", err)
+	}
+	if _, err := p.Users.GetByID(ctx, 2); err != nil {
+		t.Errorf("got %v for the user in the grace period, want it kept", err)
+	}
+
+	for _, s := range []storage.Storage{public, private} {
+		objects, err := s.List(ctx, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, object := range objects {
+			if strings.Contains(object.Key, "/1/") {
+				t.Errorf("object %s of the purged user is left", object.Key)
+			}
+		}
+		if len(objects) != 2 {
+			t.Errorf("got %d objects, want the 2 of the kept user", len(objects))
+		}
+	}
+}
+
+func TestUserPurgerKeepsRestoredUser(t *testing.T) {
+	ctx := context.Background()
+	private := storage.NewMemory("http://localhost/files", "secret")
+	p := &UserPurger{
+		Tx:       repository.MemoryTransactor{},
+		Users:    repository.NewMemoryUserRepository(),
+		Sessions: repository.NewMemorySessionRepository(),
+		Public:   &storage.Public{Storage: storage.NewMemory("http://localhost/static", "secret"), BaseURL: "http://localhost/static"},
+		Private:  private,
+		Config:   config.AccountConfig{DeletionGracePeriod: 24 * time.Hour},
+	}
+
+	// The user is listed as deleted, then restored by logging in before the purge
+	deletedAt := time.Now().Add(-48 * time.Hour)
+	listed := models.User{ID: 1, Email: "restored@example.com", DeletedAt: &deletedAt}
+	restored := listed
+	restored.DeletedAt = nil
+	if err := p.Users.Create(ctx, &restored); err != nil {
+		t.Fatal(err)
+	}
+	if err := private.Put(ctx, "uploads/1/20.png", strings.NewReader("data"), 4, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := p.purge(ctx, listed, time.Now().Add(-p.Config.DeletionGracePeriod))
+	if err != nil || deleted {
+		t.Fatalf("got deleted %t, %v, want the restored user kept", deleted, err)
+	}
+	if _, err := p.Users.GetByID(ctx, 1); err != nil {
+		t.Errorf("got %v for the restored user, want it kept", err)
+	}
+	if objects, _ := private.List(ctx, "uploads/1/"); len(objects) != 1 {
+		t.Errorf("got %d uploads, want the upload of the restored user kept", len(objects))
+	}
+}
@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yorukot/go-template/app/models"
+	"github.com/yorukot/go-template/app/repository"
+	"github.com/yorukot/go-template/pkg/logger"
+	"github.com/yorukot/go-template/pkg/metrics"
+	"github.com/yorukot/go-template/pkg/storage"
+)
+
+const (
+	// sweepBatchSize is the number of uploads loaded at once by a sweep
+	sweepBatchSize = 100
+	// uploadCompleteGrace is how long a client may still complete an upload
+	// after its link expired, an upload sent just before the expiry may take
+	// a while to arrive
+	uploadCompleteGrace = time.Hour
+)
+
+// UploadSweeper deletes the uploads that were never completed
+type UploadSweeper struct {
+	Uploads repository.UploadRepository
+	Storage storage.Storage
+}
+
+// Run deletes the pending uploads whose link expired longer than
+// uploadCompleteGrace ago, with the file sent to the link if any, and
+// returns how many were deleted. It stops at the first upload that fails,
+// which stays marked expired and is retried by the next run.
+func (s *UploadSweeper) Run(ctx context.Context) (int, error) {
+	before := time.Now().Add(-uploadCompleteGrace)
+
+	swept := 0
+	for {
+		uploads, err := s.Uploads.ListExpired(ctx, before, sweepBatchSize)
+		if err != nil {
+			return swept, fmt.Errorf("failed to list expired uploads: %w", err)
+		}
+		for _, upload := range uploads {
+			deleted, err := s.sweep(ctx, upload)
+			if err != nil {
+				return swept, fmt.Errorf("failed to sweep upload %d: %w", upload.ID, err)
+			}
+			if deleted {
+				metrics.Uploads.WithLabelValues("expired").Inc()
+				swept++
+			}
+		}
+		if len(uploads) < sweepBatchSize {
+			return swept, nil
+		}
+	}
+}
+
+// Job returns the sweep as a background job that logs what it deleted
+func (s *UploadSweeper) Job() Job {
+	return func(ctx context.Context) error {
+		swept, err := s.Run(ctx)
+		if swept > 0 {
+			logger.Log.Sugar().Infof("Deleted %d abandoned uploads", swept)
+		}
+		return err
+	}
+}
+
+// sweep marks the upload expired, then deletes its file and the upload, and
+// reports whether it was deleted. Marking it first keeps a client from
+// completing the upload while its file is being deleted.
+func (s *UploadSweeper) sweep(ctx context.Context, upload models.Upload) (bool, error) {
+	if upload.Status == models.UploadPending {
+		expired, err := s.Uploads.Expire(ctx, &upload)
+		if err != nil {
+			return false, err
+		}
+		if !expired {
+			return false, nil // Completed or swept by another instance meanwhile
+		}
+	}
+
+	if err := s.Storage.Delete(ctx, upload.ObjectKey); err != nil {
+		return false, fmt.Errorf("failed to delete file: %w", err)
+	}
+	if err := s.Uploads.Delete(ctx, upload.ID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yorukot/go-template/app/models"
+	"github.com/yorukot/go-template/app/repository"
+	"github.com/yorukot/go-template/pkg/storage"
+)
+
+func TestUploadSweeper(t *testing.T) {
+	ctx := context.Background()
+	s := &UploadSweeper{
+		Uploads: repository.NewMemoryUploadRepository(),
+		Storage: storage.NewMemory("http://localhost/files", "secret"),
+	}
+
+	abandoned := time.Now().Add(-uploadCompleteGrace - time.Hour)
+	tests := []struct {
+		upload models.Upload
+		swept  bool
+	}{
+		{upload: models.Upload{ID: 1, Status: models.UploadPending, ExpiresAt: abandoned}, swept: true},
+		{upload: models.Upload{ID: 2, Status: models.UploadExpired, ExpiresAt: time.Now().Add(time.Minute)}, swept: true},
+		// Its link expired, but the client may still complete it
+		{upload: models.Upload{ID: 3, Status: models.UploadPending, ExpiresAt: time.Now().Add(-time.Minute)}},
+		{upload: models.Upload{ID: 4, Status: models.UploadPending, ExpiresAt: time.Now().Add(time.Minute)}},
+		{upload: models.Upload{ID: 5, Status: models.UploadCompleted, ExpiresAt: abandoned}},
+	}
+	for i := range tests {
+		upload := &tests[i].upload
+		upload.UserID, upload.ContentType, upload.Size = 1, "image/png", 4
+		upload.ObjectKey = fmt.Sprintf("uploads/1/%d.png", upload.ID)
+		if err := s.Uploads.Create(ctx, upload); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Storage.Put(ctx, upload.ObjectKey, strings.NewReader("data"), 4, upload.ContentType); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	swept, err := s.Run(ctx)
+	if err != nil || swept != 2 {
+		t.Fatalf("got %d swept, %v, want 2", swept, err)
+	}
+
+	for _, tt := range tests {
+		_, uploadErr := s.Uploads.GetByID(ctx, tt.upload.ID)
+		_, fileErr := s.Storage.Stat(ctx, tt.upload.ObjectKey)
+		if tt.swept && (!errors.Is(uploadErr, repository.ErrNotFound) || !errors.Is(fileErr, storage.ErrNotFound)) {
+			t.Errorf("upload %d: got %v, %v, want the upload and its file deleted", tt.upload.ID, uploadErr, fileErr)
+		}
+		if !tt.swept && (uploadErr != nil || fileErr != nil) {
+			t.Errorf("upload %d: got %v, %v, want the upload and its file kept", tt.upload.ID, uploadErr, fileErr)
+		}
+	}
+}
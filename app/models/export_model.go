@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// Export statuses, an export moves from pending to processing, then to
+// completed or failed. Completed exports expire with their download link.
+const (
+	ExportPending    = "pending"
+	ExportProcessing = "processing"
+	ExportCompleted  = "completed"
+	ExportFailed     = "failed"
+	ExportExpired    = "expired"
+)
+
+// Export is a personal data export requested by a user, the exports table is
+// also the queue of the export job
+type Export struct {
+	ID          uint64     `json:"id,string" gorm:"primaryKey"`
+	UserID      uint64     `json:"user_id,string" gorm:"not null;index"`
+	Status      string     `json:"status" gorm:"size:16;not null;index"`
+	ObjectKey   string     `json:"-" gorm:"size:255"` // Archive in the export storage once completed
+	Error       string     `json:"-" gorm:"size:512"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // When the download link and the archive expire
+
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+}
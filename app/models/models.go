@@ -0,0 +1,12 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// AutoMigrate creates or updates the tables of every model, it is only used
+// when DATABASE_AUTO_MIGRATE is enabled, the versioned migrations in
+// migrations/ own the schema otherwise
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(&User{}, &Session{}, &ReservedEmail{}, &Export{}, &Upload{})
+}
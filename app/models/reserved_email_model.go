@@ -0,0 +1,22 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// ReservedEmail keeps the email of a purged account from signing up again
+// when ACCOUNT_RESERVE_DELETED_EMAILS is enabled. Only a hash of the email is
+// stored, so no personal data outlives the account.
+type ReservedEmail struct {
+	EmailHash string    `json:"email_hash" gorm:"primaryKey;size:64"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// HashEmail returns the hash an email is reserved under, case is ignored
+func HashEmail(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// Upload statuses, an upload is pending until the client completes it. A
+// pending upload whose link expired is marked expired by the sweep before
+// its object and record are deleted.
+const (
+	UploadPending   = "pending"
+	UploadCompleted = "completed"
+	UploadExpired   = "expired"
+)
+
+// Upload is a file the client uploads straight to the private storage through
+// a signed URL, it records the content type and the size the URL allows
+type Upload struct {
+	ID          uint64     `json:"id,string" gorm:"primaryKey"`
+	UserID      uint64     `json:"user_id,string" gorm:"not null;index"`
+	Status      string     `json:"status" gorm:"size:16;not null;index"`
+	ObjectKey   string     `json:"-" gorm:"size:255;not null"`
+	ContentType string     `json:"content_type" gorm:"size:64;not null"`
+	Size        int64      `json:"size" gorm:"not null"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"` // When the upload link expires
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+}
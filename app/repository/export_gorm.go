@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yorukot/go-template/app/models"
+	db "github.com/yorukot/go-template/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormExportRepository stores exports with GORM
+type GormExportRepository struct {
+	db *gorm.DB
+}
+
+// NewGormExportRepository creates an ExportRepository backed by db
+func NewGormExportRepository(db *gorm.DB) *GormExportRepository {
+	return &GormExportRepository{db: db}
+}
+
+// Create creates new export
+func (r *GormExportRepository) Create(ctx context.Context, export *models.Export) error {
+	return translateError(db.Conn(ctx, r.db).Omit("User").Create(export).Error)
+}
+
+// CreateUnlessRecent creates the export after checking the latest one, while
+// holding a lock on the user row so concurrent requests of the user wait for
+// each other. SQLite has no row locks but runs one write transaction at a time.
+func (r *GormExportRepository) CreateUnlessRecent(ctx context.Context, export *models.Export, since time.Time) (models.Export, bool, error) {
+	var latest models.Export
+	created := false
+	err := db.WithTx(ctx, r.db, func(ctx context.Context) error {
+		var ids []uint64
+		err := db.Conn(ctx, r.db).Model(&models.User{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", export.UserID).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return translateError(err)
+		}
+		if len(ids) == 0 {
+			return ErrNotFound
+		}
+
+		latest, err = r.LatestByUserID(ctx, export.UserID)
+		if err == nil && latest.CreatedAt.After(since) {
+			return nil
+		} else if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+
+		if err := r.Create(ctx, export); err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	if err != nil || created {
+		return models.Export{}, created, err
+	}
+	return latest, false, nil
+}
+
+// LatestByUserID gets the newest export of the user that did not fail
+func (r *GormExportRepository) LatestByUserID(ctx context.Context, userID uint64) (models.Export, error) {
+	var export models.Export
+	err := db.Conn(db.WithPrimary(ctx), r.db).
+		Where("user_id = ? AND status <> ?", userID, models.ExportFailed).
+		Order("created_at DESC").
+		First(&export).Error
+	return export, translateError(err)
+}
+
+// ListClaimable lists the pending exports and the stale processing ones
+func (r *GormExportRepository) ListClaimable(ctx context.Context, staleBefore time.Time, limit int) ([]models.Export, error) {
+	var exports []models.Export
+	err := db.Conn(db.WithPrimary(ctx), r.db).
+		Where("status = ? OR (status = ? AND started_at < ?)", models.ExportPending, models.ExportProcessing, staleBefore).
+		Order("created_at").
+		Limit(limit).
+		Find(&exports).Error
+	return exports, translateError(err)
+}
+
+// Claim marks the export as processing with a conditional update, so only one
+// worker wins it
+func (r *GormExportRepository) Claim(ctx context.Context, export *models.Export, staleBefore time.Time) (bool, error) {
+	now := time.Now()
+	result := db.Conn(ctx, r.db).Model(&models.Export{}).
+		Where("id = ? AND (status = ? OR (status = ? AND started_at < ?))", export.ID, models.ExportPending, models.ExportProcessing, staleBefore).
+		Updates(map[string]any{"status": models.ExportProcessing, "started_at": now})
+	if result.Error != nil {
+		return false, translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	export.Status = models.ExportProcessing
+	export.StartedAt = &now
+	return true, nil
+}
+
+// Update saves every field of the export except the creation time
+func (r *GormExportRepository) Update(ctx context.Context, export *models.Export) error {
+	result := db.Conn(ctx, r.db).Model(export).Select("*").Omit("id", "created_at", "User").Updates(export)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListExpired lists the completed exports that expired before the time
+func (r *GormExportRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]models.Export, error) {
+	var exports []models.Export
+	err := db.Conn(ctx, r.db).
+		Where("status = ? AND expires_at < ?", models.ExportCompleted, before).
+		Order("expires_at").
+		Limit(limit).
+		Find(&exports).Error
+	return exports, translateError(err)
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yorukot/go-template/app/models"
+)
+
+// MemoryExportRepository stores exports in memory, it is meant for tests
+type MemoryExportRepository struct {
+	mu      sync.RWMutex
+	exports map[uint64]models.Export
+}
+
+// NewMemoryExportRepository creates an empty in-memory ExportRepository
+func NewMemoryExportRepository() *MemoryExportRepository {
+	return &MemoryExportRepository{exports: map[uint64]models.Export{}}
+}
+
+// Create creates new export
+func (r *MemoryExportRepository) Create(_ context.Context, export *models.Export) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.exports[export.ID]; ok {
+		return ErrConflict
+	}
+	if export.CreatedAt.IsZero() {
+		export.CreatedAt = time.Now()
+	}
+	r.exports[export.ID] = *export
+	return nil
+}
+
+// CreateUnlessRecent creates the export unless the user has a recent one
+func (r *MemoryExportRepository) CreateUnlessRecent(_ context.Context, export *models.Export, since time.Time) (models.Export, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.exports {
+		if stored.UserID == export.UserID && stored.Status != models.ExportFailed && stored.CreatedAt.After(since) {
+			return stored, false, nil
+		}
+	}
+
+	if _, ok := r.exports[export.ID]; ok {
+		return models.Export{}, false, ErrConflict
+	}
+	if export.CreatedAt.IsZero() {
+		export.CreatedAt = time.Now()
+	}
+	r.exports[export.ID] = *export
+	return models.Export{}, true, nil
+}
+
+// LatestByUserID gets the newest export of the user that did not fail
+func (r *MemoryExportRepository) LatestByUserID(_ context.Context, userID uint64) (models.Export, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest models.Export
+	found := false
+	for _, export := range r.exports {
+		if export.UserID != userID || export.Status == models.ExportFailed {
+			continue
+		}
+		if !found || export.CreatedAt.After(latest.CreatedAt) {
+			latest, found = export, true
+		}
+	}
+	if !found {
+		return models.Export{}, ErrNotFound
+	}
+	return latest, nil
+}
+
+// ListClaimable lists the pending exports and the stale processing ones
+func (r *MemoryExportRepository) ListClaimable(_ context.Context, staleBefore time.Time, limit int) ([]models.Export, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var exports []models.Export
+	for _, export := range r.exports {
+		if claimable(export, staleBefore) {
+			exports = append(exports, export)
+		}
+	}
+	sort.Slice(exports, func(i, j int) bool { return exports[i].CreatedAt.Before(exports[j].CreatedAt) })
+	return exports[:min(limit, len(exports))], nil
+}
+
+// Claim marks the export as processing when it is still claimable
+func (r *MemoryExportRepository) Claim(_ context.Context, export *models.Export, staleBefore time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.exports[export.ID]
+	if !ok || !claimable(stored, staleBefore) {
+		return false, nil
+	}
+	now := time.Now()
+	stored.Status = models.ExportProcessing
+	stored.StartedAt = &now
+	r.exports[export.ID] = stored
+
+	export.Status = stored.Status
+	export.StartedAt = stored.StartedAt
+	return true, nil
+}
+
+// Update saves every field of the export except the creation time
+func (r *MemoryExportRepository) Update(_ context.Context, export *models.Export) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.exports[export.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	updated := *export
+	updated.CreatedAt = stored.CreatedAt
+	r.exports[export.ID] = updated
+	return nil
+}
+
+// ListExpired lists the completed exports that expired before the time
+func (r *MemoryExportRepository) ListExpired(_ context.Context, before time.Time, limit int) ([]models.Export, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var exports []models.Export
+	for _, export := range r.exports {
+		if export.Status == models.ExportCompleted && export.ExpiresAt != nil && export.ExpiresAt.Before(before) {
+			exports = append(exports, export)
+		}
+	}
+	sort.Slice(exports, func(i, j int) bool { return exports[i].ExpiresAt.Before(*exports[j].ExpiresAt) })
+	return exports[:min(limit, len(exports))], nil
+}
+
+// claimable reports whether the export is pending or processing but stale
+func claimable(export models.Export, staleBefore time.Time) bool {
+	return export.Status == models.ExportPending ||
+		(export.Status == models.ExportProcessing && export.StartedAt != nil && export.StartedAt.Before(staleBefore))
+}
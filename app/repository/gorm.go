@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/yorukot/go-template/pkg/database"
+	"gorm.io/gorm"
+)
+
+// GormTransactor runs units of work in database transactions
+type GormTransactor struct {
+	db *gorm.DB
+}
+
+// NewGormTransactor creates a Transactor backed by db
+func NewGormTransactor(db *gorm.DB) *GormTransactor {
+	return &GormTransactor{db: db}
+}
+
+// WithTx runs fn in a database transaction, see db.WithTx
+func (t *GormTransactor) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return db.WithTx(ctx, t.db, fn)
+}
+
+// translateError maps GORM and driver errors to the domain errors
+func translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case db.IsUniqueViolation(err):
+		return ErrConflict
+	default:
+		return err
+	}
+}
+
+// translateUserError maps the unique violations of users to
+// ErrEmailAlreadyUsed, IDs are generated unique so the email is the only key
+// a user can conflict on
+func translateUserError(err error) error {
+	err = translateError(err)
+	if errors.Is(err, ErrConflict) {
+		return ErrEmailAlreadyUsed
+	}
+	return err
+}
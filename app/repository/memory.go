@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+)
+
+// MemoryTransactor runs units of work for the memory repositories, which have
+// no rollback, so a failing unit of work keeps its earlier writes. It is
+// meant for tests.
+type MemoryTransactor struct{}
+
+// WithTx runs fn without a transaction
+func (MemoryTransactor) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yorukot/go-template/app/models"
+)
+
+// Domain errors returned by every repository implementation, callers compare
+// against these with errors.Is instead of driver specific errors
+var (
+	ErrNotFound = errors.New("record not found")
+	ErrConflict = errors.New("record conflicts with an existing record")
+	// ErrEmailAlreadyUsed is the ErrConflict of users, it matches both
+	ErrEmailAlreadyUsed = fmt.Errorf("email is already used: %w", ErrConflict)
+)
+
+// Transactor runs units of work whose repository calls share a transaction
+type Transactor interface {
+	// WithTx runs fn in a transaction, the repository calls made with the
+	// context passed to fn join it. The transaction is rolled back when fn
+	// returns an error.
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// UserRepository stores users
+type UserRepository interface {
+	// GetByID returns ErrNotFound when no user has the ID
+	GetByID(ctx context.Context, id uint64) (models.User, error)
+	// GetByEmail returns ErrNotFound when no user has the email
+	GetByEmail(ctx context.Context, email string) (models.User, error)
+	// Create returns ErrEmailAlreadyUsed when the email is already used
+	Create(ctx context.Context, user *models.User) error
+	// Update saves every field of the user, it returns ErrNotFound when the
+	// user does not exist and ErrEmailAlreadyUsed when the email is already used
+	Update(ctx context.Context, user *models.User) error
+	// UpdateProfile saves only the display name, the language and the update
+	// time, so concurrent changes to other fields are kept. It returns
+	// ErrNotFound when the user does not exist.
+	UpdateProfile(ctx context.Context, user *models.User) error
+	// UpdateAvatar saves only the avatar and the update time, it returns
+	// ErrNotFound when the user does not exist
+	UpdateAvatar(ctx context.Context, user *models.User) error
+	// MarkDeleted saves only the deletion and the update time, it returns
+	// ErrNotFound when the user does not exist
+	MarkDeleted(ctx context.Context, user *models.User) error
+	// Restore clears the deletion time and saves the update time of a
+	// soft-deleted user. It returns ErrNotFound when the user does not exist
+	// or is not deleted, so a restore racing the purge never succeeds twice.
+	Restore(ctx context.Context, user *models.User) error
+	// Delete removes the user for good, it returns ErrNotFound when the user
+	// does not exist. The database deletes the sessions of the user.
+	Delete(ctx context.Context, id uint64) error
+	// DeleteIfDeletedBefore removes the user for good if it was soft-deleted
+	// before the time. It returns ErrNotFound when the user does not exist,
+	// is not deleted or was deleted since, so a restored account is kept.
+	DeleteIfDeletedBefore(ctx context.Context, id uint64, before time.Time) error
+	// ListDeletedBefore returns up to limit users soft-deleted before the time, oldest first
+	ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]models.User, error)
+	// ReserveEmail keeps the email from signing up again, reserving an email twice is not an error
+	ReserveEmail(ctx context.Context, email string) error
+	// IsEmailReserved reports whether the email was reserved
+	IsEmailReserved(ctx context.Context, email string) (bool, error)
+}
+
+// SessionRepository stores refresh token sessions
+type SessionRepository interface {
+	// Create returns ErrConflict when the session ID or secret key is already used
+	Create(ctx context.Context, session *models.Session) error
+	// GetBySecretKey returns ErrNotFound when no session has the secret key
+	GetBySecretKey(ctx context.Context, secretKey string) (models.Session, error)
+	// ListByUserID returns every session of the user, newest first
+	ListByUserID(ctx context.Context, userID uint64) ([]models.Session, error)
+	// DeleteBySecretKey deletes the session, a missing session is not an error
+	DeleteBySecretKey(ctx context.Context, secretKey string) error
+	// DeleteByUserID deletes every session of the user and returns how many were deleted
+	DeleteByUserID(ctx context.Context, userID uint64) (int64, error)
+	// DeleteExpired deletes the sessions that expired before the time and returns how many were deleted
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ExportRepository stores personal data exports, it doubles as the queue of
+// the export job
+type ExportRepository interface {
+	Create(ctx context.Context, export *models.Export) error
+	// CreateUnlessRecent creates the export unless the user has an export that
+	// did not fail created after since, it then returns that export and false.
+	// The check and the insert are atomic, so concurrent requests create one export.
+	CreateUnlessRecent(ctx context.Context, export *models.Export, since time.Time) (models.Export, bool, error)
+	// LatestByUserID returns the newest export of the user that did not fail,
+	// it returns ErrNotFound when there is none
+	LatestByUserID(ctx context.Context, userID uint64) (models.Export, error)
+	// ListClaimable returns up to limit exports that are pending, or processing
+	// but started before staleBefore, oldest first
+	ListClaimable(ctx context.Context, staleBefore time.Time, limit int) ([]models.Export, error)
+	// Claim marks the export as processing when it is still claimable, it
+	// reports false when another worker claimed it first
+	Claim(ctx context.Context, export *models.Export, staleBefore time.Time) (bool, error)
+	// Update saves every field of the export, it returns ErrNotFound when the
+	// export does not exist
+	Update(ctx context.Context, export *models.Export) error
+	// ListExpired returns up to limit completed exports that expired before the time
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]models.Export, error)
+}
+
+// UploadRepository stores the uploads made through signed URLs
+type UploadRepository interface {
+	Create(ctx context.Context, upload *models.Upload) error
+	// GetByID returns ErrNotFound when no upload has the ID
+	GetByID(ctx context.Context, id uint64) (models.Upload, error)
+	// Complete marks the upload as completed when it is still pending, it
+	// reports false when the upload was completed or expired meanwhile
+	Complete(ctx context.Context, upload *models.Upload) (bool, error)
+	// Expire marks the upload as expired when it is still pending, it reports
+	// false when the upload was completed or expired meanwhile
+	Expire(ctx context.Context, upload *models.Upload) (bool, error)
+	// ListExpired returns up to limit uploads that are pending with a link that
+	// expired before the time, or already marked expired, oldest first
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]models.Upload, error)
+	// Delete removes the upload, a missing upload is not an error
+	Delete(ctx context.Context, id uint64) error
+}
+
+// Every implementation satisfies the interfaces
+var (
+	_ Transactor        = (*GormTransactor)(nil)
+	_ Transactor        = MemoryTransactor{}
+	_ UserRepository    = (*GormUserRepository)(nil)
+	_ UserRepository    = (*MemoryUserRepository)(nil)
+	_ SessionRepository = (*GormSessionRepository)(nil)
+	_ SessionRepository = (*MemorySessionRepository)(nil)
+	_ ExportRepository  = (*GormExportRepository)(nil)
+	_ ExportRepository  = (*MemoryExportRepository)(nil)
+	_ UploadRepository  = (*GormUploadRepository)(nil)
+	_ UploadRepository  = (*MemoryUploadRepository)(nil)
+)
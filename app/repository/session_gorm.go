@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/yorukot/go-template/app/models"
+	db "github.com/yorukot/go-template/pkg/database"
+	"gorm.io/gorm"
+)
+
+// GormSessionRepository stores sessions with GORM
+type GormSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewGormSessionRepository creates a SessionRepository backed by db
+func NewGormSessionRepository(db *gorm.DB) *GormSessionRepository {
+	return &GormSessionRepository{db: db}
+}
+
+// Create creates new session
+func (r *GormSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	return translateError(db.Conn(ctx, r.db).Create(session).Error)
+}
+
+// GetBySecretKey gets session by secretKey, from the primary so a lagging
+// replica never accepts a revoked session
+func (r *GormSessionRepository) GetBySecretKey(ctx context.Context, secretKey string) (models.Session, error) {
+	var session models.Session
+	err := db.Conn(db.WithPrimary(ctx), r.db).Where("secret_key = ?", secretKey).First(&session).Error
+	return session, translateError(err)
+}
+
+// ListByUserID lists every session of the user
+func (r *GormSessionRepository) ListByUserID(ctx context.Context, userID uint64) ([]models.Session, error) {
+	var sessions []models.Session
+	err := db.Conn(ctx, r.db).Where("user_id = ?", userID).Order("created_at DESC").Find(&sessions).Error
+	return sessions, translateError(err)
+}
+
+// DeleteBySecretKey deletes session by secretKey
+func (r *GormSessionRepository) DeleteBySecretKey(ctx context.Context, secretKey string) error {
+	return translateError(db.Conn(ctx, r.db).Where("secret_key = ?", secretKey).Delete(&models.Session{}).Error)
+}
+
+// DeleteByUserID deletes every session of the user
+func (r *GormSessionRepository) DeleteByUserID(ctx context.Context, userID uint64) (int64, error) {
+	result := db.Conn(ctx, r.db).Where("user_id = ?", userID).Delete(&models.Session{})
+	return result.RowsAffected, translateError(result.Error)
+}
+
+// DeleteExpired deletes the sessions that expired before the time
+func (r *GormSessionRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := db.Conn(ctx, r.db).Where("expires_at < ?", before).Delete(&models.Session{})
+	return result.RowsAffected, translateError(result.Error)
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yorukot/go-template/app/models"
+)
+
+// MemorySessionRepository stores sessions in memory, it is meant for tests
+type MemorySessionRepository struct {
+	mu       sync.RWMutex
+	sessions map[string]models.Session // by secret key
+}
+
+// NewMemorySessionRepository creates an empty in-memory SessionRepository
+func NewMemorySessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{sessions: map[string]models.Session{}}
+}
+
+// Create creates new session
+func (r *MemorySessionRepository) Create(_ context.Context, session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sessions[session.SecretKey]; ok {
+		return ErrConflict
+	}
+	for _, existing := range r.sessions {
+		if existing.SessionID == session.SessionID {
+			return ErrConflict
+		}
+	}
+
+	r.sessions[session.SecretKey] = *session
+	return nil
+}
+
+// GetBySecretKey gets session by secretKey
+func (r *MemorySessionRepository) GetBySecretKey(_ context.Context, secretKey string) (models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.sessions[secretKey]
+	if !ok {
+		return models.Session{}, ErrNotFound
+	}
+	return session, nil
+}
+
+// ListByUserID lists every session of the user
+func (r *MemorySessionRepository) ListByUserID(_ context.Context, userID uint64) ([]models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sessions []models.Session
+	for _, session := range r.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].CreatedAt.After(sessions[j].CreatedAt) })
+	return sessions, nil
+}
+
+// DeleteBySecretKey deletes session by secretKey
+func (r *MemorySessionRepository) DeleteBySecretKey(_ context.Context, secretKey string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.sessions, secretKey)
+	return nil
+}
+
+// DeleteByUserID deletes every session of the user
+func (r *MemorySessionRepository) DeleteByUserID(_ context.Context, userID uint64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for secretKey, session := range r.sessions {
+		if session.UserID == userID {
+			delete(r.sessions, secretKey)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// DeleteExpired deletes the sessions that expired before the time
+func (r *MemorySessionRepository) DeleteExpired(_ context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for secretKey, session := range r.sessions {
+		if session.ExpiresAt.Before(before) {
+			delete(r.sessions, secretKey)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/yorukot/go-template/app/models"
+	db "github.com/yorukot/go-template/pkg/database"
+	"gorm.io/gorm"
+)
+
+// GormUploadRepository stores uploads with GORM
+type GormUploadRepository struct {
+	db *gorm.DB
+}
+
+// NewGormUploadRepository creates an UploadRepository backed by db
+func NewGormUploadRepository(db *gorm.DB) *GormUploadRepository {
+	return &GormUploadRepository{db: db}
+}
+
+// Create creates new upload
+func (r *GormUploadRepository) Create(ctx context.Context, upload *models.Upload) error {
+	return translateError(db.Conn(ctx, r.db).Omit("User").Create(upload).Error)
+}
+
+// GetByID gets the upload by its ID, from the primary as the client completes
+// an upload right after creating it
+func (r *GormUploadRepository) GetByID(ctx context.Context, id uint64) (models.Upload, error) {
+	var upload models.Upload
+	err := db.Conn(db.WithPrimary(ctx), r.db).First(&upload, "id = ?", id).Error
+	return upload, translateError(err)
+}
+
+// Complete marks the upload as completed with a conditional update, so a
+// sweep that expires it at the same time wins or loses as a whole
+func (r *GormUploadRepository) Complete(ctx context.Context, upload *models.Upload) (bool, error) {
+	now := time.Now()
+	ok, err := r.leavePending(ctx, upload.ID, map[string]any{"status": models.UploadCompleted, "completed_at": now})
+	if ok {
+		upload.Status = models.UploadCompleted
+		upload.CompletedAt = &now
+	}
+	return ok, err
+}
+
+// Expire marks the upload as expired with a conditional update
+func (r *GormUploadRepository) Expire(ctx context.Context, upload *models.Upload) (bool, error) {
+	ok, err := r.leavePending(ctx, upload.ID, map[string]any{"status": models.UploadExpired})
+	if ok {
+		upload.Status = models.UploadExpired
+	}
+	return ok, err
+}
+
+// ListExpired lists the pending uploads whose link expired before the time and the expired ones
+func (r *GormUploadRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]models.Upload, error) {
+	var uploads []models.Upload
+	err := db.Conn(db.WithPrimary(ctx), r.db).
+		Where("(status = ? AND expires_at < ?) OR status = ?", models.UploadPending, before, models.UploadExpired).
+		Order("expires_at").
+		Limit(limit).
+		Find(&uploads).Error
+	return uploads, translateError(err)
+}
+
+// Delete deletes the upload
+func (r *GormUploadRepository) Delete(ctx context.Context, id uint64) error {
+	return translateError(db.Conn(ctx, r.db).Delete(&models.Upload{}, "id = ?", id).Error)
+}
+
+// leavePending updates the upload when it is still pending and reports whether it was
+func (r *GormUploadRepository) leavePending(ctx context.Context, id uint64, updates map[string]any) (bool, error) {
+	result := db.Conn(ctx, r.db).Model(&models.Upload{}).
+		Where("id = ? AND status = ?", id, models.UploadPending).
+		Updates(updates)
+	if result.Error != nil {
+		return false, translateError(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yorukot/go-template/app/models"
+)
+
+// MemoryUploadRepository stores uploads in memory, it is meant for tests
+type MemoryUploadRepository struct {
+	mu      sync.RWMutex
+	uploads map[uint64]models.Upload
+}
+
+// NewMemoryUploadRepository creates an empty in-memory UploadRepository
+func NewMemoryUploadRepository() *MemoryUploadRepository {
+	return &MemoryUploadRepository{uploads: map[uint64]models.Upload{}}
+}
+
+// Create creates new upload
+func (r *MemoryUploadRepository) Create(_ context.Context, upload *models.Upload) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.uploads[upload.ID]; ok {
+		return ErrConflict
+	}
+	if upload.CreatedAt.IsZero() {
+		upload.CreatedAt = time.Now()
+	}
+	r.uploads[upload.ID] = *upload
+	return nil
+}
+
+// GetByID gets the upload by its ID
+func (r *MemoryUploadRepository) GetByID(_ context.Context, id uint64) (models.Upload, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	upload, ok := r.uploads[id]
+	if !ok {
+		return models.Upload{}, ErrNotFound
+	}
+	return upload, nil
+}
+
+// Complete marks the upload as completed when it is still pending
+func (r *MemoryUploadRepository) Complete(_ context.Context, upload *models.Upload) (bool, error) {
+	now := time.Now()
+	return r.leavePending(upload, func(stored *models.Upload) {
+		stored.Status = models.UploadCompleted
+		stored.CompletedAt = &now
+	}), nil
+}
+
+// Expire marks the upload as expired when it is still pending
+func (r *MemoryUploadRepository) Expire(_ context.Context, upload *models.Upload) (bool, error) {
+	return r.leavePending(upload, func(stored *models.Upload) {
+		stored.Status = models.UploadExpired
+	}), nil
+}
+
+// ListExpired lists the pending uploads whose link expired before the time and the expired ones
+func (r *MemoryUploadRepository) ListExpired(_ context.Context, before time.Time, limit int) ([]models.Upload, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var uploads []models.Upload
+	for _, upload := range r.uploads {
+		if (upload.Status == models.UploadPending && upload.ExpiresAt.Before(before)) || upload.Status == models.UploadExpired {
+			uploads = append(uploads, upload)
+		}
+	}
+	sort.Slice(uploads, func(i, j int) bool { return uploads[i].ExpiresAt.Before(uploads[j].ExpiresAt) })
+	return uploads[:min(limit, len(uploads))], nil
+}
+
+// Delete deletes the upload
+func (r *MemoryUploadRepository) Delete(_ context.Context, id uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.uploads, id)
+	return nil
+}
+
+// leavePending applies the update to the upload when it is still pending,
+// then copies the stored upload back, and reports whether it was
+func (r *MemoryUploadRepository) leavePending(upload *models.Upload, update func(stored *models.Upload)) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.uploads[upload.ID]
+	if !ok || stored.Status != models.UploadPending {
+		return false
+	}
+	update(&stored)
+	r.uploads[upload.ID] = stored
+
+	upload.Status = stored.Status
+	upload.CompletedAt = stored.CompletedAt
+	return true
+}
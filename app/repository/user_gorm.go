@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/yorukot/go-template/app/models"
+	db "github.com/yorukot/go-template/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormUserRepository stores users with GORM
+type GormUserRepository struct {
+	db *gorm.DB
+}
+
+// NewGormUserRepository creates a UserRepository backed by db
+func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
+	return &GormUserRepository{db: db}
+}
+
+// GetByID gets user by user ID
+func (r *GormUserRepository) GetByID(ctx context.Context, id uint64) (models.User, error) {
+	var user models.User
+	err := db.Conn(ctx, r.db).Where("id = ?", id).First(&user).Error
+	return user, translateError(err)
+}
+
+// GetByEmail gets user by email
+func (r *GormUserRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	err := db.Conn(ctx, r.db).Where("email = ?", email).First(&user).Error
+	return user, translateError(err)
+}
+
+// Create creates new user data
+func (r *GormUserRepository) Create(ctx context.Context, user *models.User) error {
+	return translateUserError(db.Conn(ctx, r.db).Create(user).Error)
+}
+
+// Update saves every field of the user except the creation time
+func (r *GormUserRepository) Update(ctx context.Context, user *models.User) error {
+	result := db.Conn(ctx, r.db).Model(user).Select("*").Omit("id", "created_at").Updates(user)
+	if result.Error != nil {
+		return translateUserError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateProfile saves the profile columns of the user
+func (r *GormUserRepository) UpdateProfile(ctx context.Context, user *models.User) error {
+	return r.updateColumns(ctx, user, "display_name", "language", "updated_at")
+}
+
+// UpdateAvatar saves the avatar column of the user
+func (r *GormUserRepository) UpdateAvatar(ctx context.Context, user *models.User) error {
+	return r.updateColumns(ctx, user, "avatar", "updated_at")
+}
+
+// MarkDeleted saves the deletion columns of the user
+func (r *GormUserRepository) MarkDeleted(ctx context.Context, user *models.User) error {
+	return r.updateColumns(ctx, user, "deleted_at", "updated_at")
+}
+
+// Restore clears the deletion time of the user if it is still deleted
+func (r *GormUserRepository) Restore(ctx context.Context, user *models.User) error {
+	result := db.Conn(ctx, r.db).Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", user.ID).
+		Updates(map[string]interface{}{"deleted_at": nil, "updated_at": user.UpdatedAt})
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	user.DeletedAt = nil
+	return nil
+}
+
+// updateColumns saves the columns of the user, zero values included
+func (r *GormUserRepository) updateColumns(ctx context.Context, user *models.User, columns ...string) error {
+	result := db.Conn(ctx, r.db).Model(user).Select(columns).Updates(user)
+	if result.Error != nil {
+		return translateUserError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete deletes the user, its sessions are deleted by the foreign key
+func (r *GormUserRepository) Delete(ctx context.Context, id uint64) error {
+	result := db.Conn(ctx, r.db).Where("id = ?", id).Delete(&models.User{})
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteIfDeletedBefore deletes the user if its deletion time is before the
+// time, its sessions are deleted by the foreign key
+func (r *GormUserRepository) DeleteIfDeletedBefore(ctx context.Context, id uint64, before time.Time) error {
+	result := db.Conn(ctx, r.db).Where("id = ? AND deleted_at < ?", id, before).Delete(&models.User{})
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListDeletedBefore lists the users soft-deleted before the time
+func (r *GormUserRepository) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]models.User, error) {
+	var users []models.User
+	err := db.Conn(ctx, r.db).Where("deleted_at < ?", before).Order("deleted_at").Limit(limit).Find(&users).Error
+	return users, translateError(err)
+}
+
+// ReserveEmail stores the hash of the email
+func (r *GormUserRepository) ReserveEmail(ctx context.Context, email string) error {
+	reserved := models.ReservedEmail{EmailHash: models.HashEmail(email)}
+	return translateError(db.Conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(&reserved).Error)
+}
+
+// IsEmailReserved looks up the hash of the email
+func (r *GormUserRepository) IsEmailReserved(ctx context.Context, email string) (bool, error) {
+	var count int64
+	err := db.Conn(ctx, r.db).Model(&models.ReservedEmail{}).Where("email_hash = ?", models.HashEmail(email)).Count(&count).Error
+	return count > 0, translateError(err)
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yorukot/go-template/app/models"
+)
+
+// MemoryUserRepository stores users in memory, it is meant for tests
+type MemoryUserRepository struct {
+	mu       sync.RWMutex
+	users    map[uint64]models.User
+	reserved map[string]bool // email hashes
+}
+
+// NewMemoryUserRepository creates an empty in-memory UserRepository
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: map[uint64]models.User{}, reserved: map[string]bool{}}
+}
+
+// GetByID gets user by user ID
+func (r *MemoryUserRepository) GetByID(_ context.Context, id uint64) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+// GetByEmail gets user by email
+func (r *MemoryUserRepository) GetByEmail(_ context.Context, email string) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+// Create creates new user data
+func (r *MemoryUserRepository) Create(_ context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; ok {
+		return ErrConflict
+	}
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return ErrEmailAlreadyUsed
+		}
+	}
+
+	r.users[user.ID] = *user
+	return nil
+}
+
+// Update saves every field of the user
+func (r *MemoryUserRepository) Update(_ context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; !ok {
+		return ErrNotFound
+	}
+	for _, existing := range r.users {
+		if existing.ID != user.ID && existing.Email == user.Email {
+			return ErrEmailAlreadyUsed
+		}
+	}
+
+	r.users[user.ID] = *user
+	return nil
+}
+
+// UpdateProfile saves the profile fields of the user
+func (r *MemoryUserRepository) UpdateProfile(_ context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.DisplayName, stored.Language, stored.UpdatedAt = user.DisplayName, user.Language, user.UpdatedAt
+	r.users[user.ID] = stored
+	return nil
+}
+
+// UpdateAvatar saves the avatar of the user
+func (r *MemoryUserRepository) UpdateAvatar(_ context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.Avatar, stored.UpdatedAt = user.Avatar, user.UpdatedAt
+	r.users[user.ID] = stored
+	return nil
+}
+
+// MarkDeleted saves the deletion time of the user
+func (r *MemoryUserRepository) MarkDeleted(_ context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.DeletedAt, stored.UpdatedAt = user.DeletedAt, user.UpdatedAt
+	r.users[user.ID] = stored
+	return nil
+}
+
+// Restore clears the deletion time of the user if it is still deleted
+func (r *MemoryUserRepository) Restore(_ context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok || stored.DeletedAt == nil {
+		return ErrNotFound
+	}
+	stored.DeletedAt, stored.UpdatedAt = nil, user.UpdatedAt
+	r.users[user.ID] = stored
+	user.DeletedAt = nil
+	return nil
+}
+
+// Delete deletes the user, the sessions are kept by the memory repositories
+func (r *MemoryUserRepository) Delete(_ context.Context, id uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// DeleteIfDeletedBefore deletes the user if its deletion time is before the time
+func (r *MemoryUserRepository) DeleteIfDeletedBefore(_ context.Context, id uint64, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt == nil || !user.DeletedAt.Before(before) {
+		return ErrNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// ListDeletedBefore lists the users soft-deleted before the time
+func (r *MemoryUserRepository) ListDeletedBefore(_ context.Context, before time.Time, limit int) ([]models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []models.User
+	for _, user := range r.users {
+		if user.DeletedAt != nil && user.DeletedAt.Before(before) {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].DeletedAt.Before(*users[j].DeletedAt)
+	})
+	return users[:min(limit, len(users))], nil
+}
+
+// ReserveEmail stores the hash of the email
+func (r *MemoryUserRepository) ReserveEmail(_ context.Context, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reserved[models.HashEmail(email)] = true
+	return nil
+}
+
+// IsEmailReserved looks up the hash of the email
+func (r *MemoryUserRepository) IsEmailReserved(_ context.Context, email string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.reserved[models.HashEmail(email)], nil
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/app"
+	adminCtrl "github.com/yorukot/go-template/app/controllers/admin"
+	"github.com/yorukot/go-template/pkg/middleware"
+)
+
+func AdminRoute(r *gin.RouterGroup, a *app.App) {
+	adminGroup := r.Group("/admin")
+	adminGroup.Use(middleware.IsAuthorized())
+	adminGroup.Use(middleware.IsAdmin(a.Users))
+
+	adminGroup.GET("/log-level", adminCtrl.GetLogLevel)
+	adminGroup.PUT("/log-level", adminCtrl.SetLogLevel)
+}
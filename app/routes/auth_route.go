@@ -2,12 +2,19 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/app"
 	authCtrl "github.com/yorukot/go-template/app/controllers/auth"
 )
 
-func AuthRoute(r *gin.RouterGroup) {
+func AuthRoute(r *gin.RouterGroup, a *app.App) {
+	handler := authCtrl.NewHandler(a.Tx, a.Users, a.Sessions)
+
+	// The responses are kept out of caches by NoStoreUnder in cmd/serve.go,
+	// which also covers the CSRF rejections
 	authGroup := r.Group("/auth")
 
-	authGroup.POST("/signup", authCtrl.Signup)
-	authGroup.POST("/login", authCtrl.Login)
+	authGroup.GET("/csrf", authCtrl.GetCSRFToken)
+	authGroup.POST("/signup", handler.Signup)
+	authGroup.POST("/login", handler.Login)
+	authGroup.POST("/refresh", handler.Refresh)
 }
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	healthCtrl "github.com/yorukot/go-template/app/controllers/health"
+)
+
+func HealthRoute(r *gin.RouterGroup) {
+	r.GET("/healthz", healthCtrl.Liveness)
+	r.GET("/readyz", healthCtrl.Readiness)
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/app"
+	filesCtrl "github.com/yorukot/go-template/app/controllers/files"
+	"github.com/yorukot/go-template/pkg/storage"
+)
+
+// StorageRoute serves the files of the local and memory backends, S3 serves its
+// own. The signatures of the URLs authorize the requests, so the routes are
+// registered outside of the CSRF middleware.
+func StorageRoute(r *gin.RouterGroup, a *app.App) {
+	switch public := a.Public.Storage.(type) {
+	case *storage.Local:
+		r.Static(storage.StaticRoute, public.Dir())
+	case *storage.Memory:
+		r.GET(storage.StaticRoute+"/*key", filesCtrl.Serve(public))
+	}
+
+	if verifier, ok := a.Private.(storage.Verifier); ok {
+		r.GET(storage.DownloadRoute+"/*key", filesCtrl.Download(a.Private, verifier))
+		r.PUT(storage.DownloadRoute+"/*key", filesCtrl.Upload(a.Private, verifier))
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/app"
+	uploadCtrl "github.com/yorukot/go-template/app/controllers/upload"
+	"github.com/yorukot/go-template/pkg/middleware"
+)
+
+func UploadRoute(r *gin.RouterGroup, a *app.App) {
+	handler := uploadCtrl.NewHandler(a.Uploads, a.Users, a.Private)
+
+	uploadGroup := r.Group("/uploads")
+	uploadGroup.Use(middleware.IsAuthorized())
+
+	uploadGroup.POST("", handler.CreateUpload)
+	uploadGroup.POST("/:id/complete", handler.CompleteUpload)
+}
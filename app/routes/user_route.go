@@ -2,13 +2,20 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/app"
 	userCtrl "github.com/yorukot/go-template/app/controllers/user"
 	"github.com/yorukot/go-template/pkg/middleware"
 )
 
-func UserRoute(r *gin.RouterGroup) {
+func UserRoute(r *gin.RouterGroup, a *app.App) {
+	handler := userCtrl.NewHandler(a.Tx, a.Users, a.Sessions, a.Exports, a.Public)
+
 	userGroup := r.Group("/user")
 	userGroup.Use(middleware.IsAuthorized())
 
-	userGroup.GET("/profile", userCtrl.GetProfile)
+	userGroup.GET("/profile", handler.GetProfile)
+	userGroup.PATCH("/profile", handler.UpdateProfile)
+	userGroup.PUT("/avatar", handler.UploadAvatar)
+	userGroup.DELETE("/account", handler.DeleteAccount)
+	userGroup.POST("/export", handler.RequestExport)
 }
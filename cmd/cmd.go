@@ -0,0 +1,161 @@
+// Package cmd holds the command line interface. Every command runs from the
+// same binary as the server, so operations reuse the repositories and the
+// encryption package instead of hand-written SQL.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/yorukot/go-template/pkg/config"
+	db "github.com/yorukot/go-template/pkg/database"
+	"github.com/yorukot/go-template/pkg/encryption"
+	"github.com/yorukot/go-template/pkg/logger"
+	"github.com/yorukot/go-template/pkg/utils"
+	"go.uber.org/zap/zapcore"
+	"gorm.io/gorm"
+)
+
+// binaryName is the program name shown in usage messages
+const binaryName = "app"
+
+// command is a node of the command tree, a command either runs or has subcommands
+type command struct {
+	name        string
+	args        string // argument synopsis shown in the usage line
+	summary     string
+	run         func(name string, args []string) int
+	subcommands []*command
+}
+
+// Execute runs the command named by the arguments and returns the process
+// exit code. Without arguments it serves the API, like the binary always did.
+func Execute(args []string) int {
+	root := &command{
+		name: binaryName,
+		run:  runServe,
+		subcommands: []*command{
+			serveCommand(),
+			migrateCommand(),
+			userCommand(),
+			sessionsCommand(),
+			keysCommand(),
+			configCommand(),
+		},
+	}
+	return root.execute(binaryName, args)
+}
+
+// execute dispatches the arguments to the matching subcommand
+func (c *command) execute(name string, args []string) int {
+	if len(c.subcommands) == 0 {
+		return c.run(name, args)
+	}
+
+	if len(args) == 0 {
+		if c.run != nil {
+			return c.run(name, args)
+		}
+		c.printUsage(os.Stderr, name)
+		return 2
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		c.printUsage(os.Stdout, name)
+		return 0
+	}
+
+	for _, sub := range c.subcommands {
+		if sub.name == args[0] {
+			return sub.execute(name+" "+sub.name, args[1:])
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", args[0])
+	c.printUsage(os.Stderr, name)
+	return 2
+}
+
+// printUsage lists the subcommands with their summaries
+func (c *command) printUsage(w io.Writer, name string) {
+	fmt.Fprintf(w, "Usage: %s <command>\n\nCommands:\n", name)
+
+	width := 0
+	for _, sub := range c.subcommands {
+		width = max(width, len(sub.synopsis()))
+	}
+	for _, sub := range c.subcommands {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, sub.synopsis(), sub.summary)
+	}
+	fmt.Fprintf(w, "\nRun \"%s <command> -h\" for the flags of a command.\n", name)
+}
+
+// synopsis returns the name and the arguments of the command
+func (c *command) synopsis() string {
+	return strings.TrimSpace(c.name + " " + c.args)
+}
+
+// newFlagSet returns a flag set whose usage message names the full command
+func newFlagSet(name string, args string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s\n", strings.TrimSpace(name+" "+args))
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags parses the arguments and returns the exit code to stop with, or
+// -1 when the command should continue
+func parseFlags(flags *flag.FlagSet, args []string, nArgs int) int {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() != nArgs {
+		flags.Usage()
+		return 2
+	}
+	return -1
+}
+
+// setup loads the configuration and configures the shared packages the way
+// app.New does, without connecting to anything
+func setup() *config.Config {
+	cfg := config.Get()
+	logger.Init(cfg)
+	if cfg.Log.Level == "" {
+		// Connection logs would bury the output of the command
+		logger.Level.SetLevel(zapcore.WarnLevel)
+	}
+	encryption.Init(cfg)
+	utils.Init(cfg)
+	return cfg
+}
+
+// openDatabase runs setup and connects to the database, the caller closes it.
+// Commands only use the primary so they read their own writes.
+func openDatabase() (*config.Config, *gorm.DB, error) {
+	cfg := setup()
+	dbConfig := cfg.Database
+	dbConfig.Replicas = nil
+	conn, err := db.Open(context.Background(), dbConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, conn, nil
+}
+
+// fail prints the error and returns the exit code of a failed command
+func fail(err error) int {
+	fmt.Fprintln(os.Stderr, err)
+	return 1
+}
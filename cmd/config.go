@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/yorukot/go-template/pkg/config"
+)
+
+// configCommand inspects the configuration
+func configCommand() *command {
+	return &command{
+		name:    "config",
+		summary: "Inspect the configuration",
+		subcommands: []*command{
+			{name: "check", args: "[-print]", summary: "Validate the configuration and list every problem", run: configCheck},
+		},
+	}
+}
+
+// configCheck loads the configuration the way the server does, without
+// connecting to anything, so a deploy can be validated before it starts
+func configCheck(name string, args []string) int {
+	flags := newFlagSet(name, "[-print]")
+	printConfig := flags.Bool("print", false, "print the resolved configuration with secrets redacted")
+	if code := parseFlags(flags, args, 0); code >= 0 {
+		return code
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fail(err)
+	}
+
+	if *printConfig {
+		fmt.Print(cfg.Dump())
+	}
+	fmt.Println("Configuration is valid")
+	return 0
+}
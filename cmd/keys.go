@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yorukot/go-template/pkg/encryption"
+)
+
+// jwtSecretKeyLength is the length of the generated JWT secret keys
+const jwtSecretKeyLength = 64
+
+// keysCommand manages the signing keys
+func keysCommand() *command {
+	return &command{
+		name:    "keys",
+		summary: "Manage the JWT signing keys",
+		subcommands: []*command{
+			{name: "rotate", args: "[-keep N] [-write FILE]", summary: "Generate a new JWT secret key and keep the current one for verification", run: keysRotate},
+		},
+	}
+}
+
+// keysRotate generates a new JWT_SECRET_KEY and moves the current key to
+// JWT_PREVIOUS_SECRET_KEYS, so access tokens signed before the rotation stay
+// valid until they expire. Tokens carry the ID of their key, which is how the
+// verifier picks the key.
+func keysRotate(name string, args []string) int {
+	flags := newFlagSet(name, "[-keep N] [-write FILE]")
+	keep := flags.Int("keep", 1, "number of previous keys to keep for verification")
+	write := flags.String("write", "", "update the variables in this env file instead of printing them")
+	if code := parseFlags(flags, args, 0); code >= 0 {
+		return code
+	}
+	if *keep < 0 {
+		fmt.Fprintln(os.Stderr, "-keep must not be negative")
+		return 2
+	}
+
+	cfg := setup()
+
+	secretKey, err := encryption.RandStringRunes(jwtSecretKeyLength, true)
+	if err != nil {
+		return fail(fmt.Errorf("failed to generate key: %w", err))
+	}
+
+	previous := append([]string{cfg.Cookie.JWTSecretKey}, cfg.Cookie.JWTPreviousSecretKeys...)
+	previous = previous[:min(*keep, len(previous))]
+
+	values := map[string]string{
+		"JWT_SECRET_KEY":           secretKey,
+		"JWT_PREVIOUS_SECRET_KEYS": strings.Join(previous, ","),
+	}
+
+	if *write == "" {
+		fmt.Printf("JWT_SECRET_KEY=%s\n", values["JWT_SECRET_KEY"])
+		fmt.Printf("JWT_PREVIOUS_SECRET_KEYS=%s\n", values["JWT_PREVIOUS_SECRET_KEYS"])
+		fmt.Fprintln(os.Stderr, "Set these variables and restart every instance to finish the rotation")
+		return 0
+	}
+
+	if err := updateEnvFile(*write, values); err != nil {
+		return fail(err)
+	}
+	fmt.Printf("Rotated to key %s in %s, restart every instance to finish the rotation\n", encryption.JwtKeyID(secretKey), *write)
+	return 0
+}
+
+// updateEnvFile replaces the variables in the env file and appends the
+// missing ones, keeping every other line and the file mode
+func updateEnvFile(path string, values map[string]string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	written := map[string]bool{}
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		export := strings.HasPrefix(line, "export ")
+		key, _, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if value, ok := values[key]; found && ok {
+			lines[i] = key + "=" + value
+			if export {
+				lines[i] = "export " + lines[i]
+			}
+			written[key] = true
+		}
+	}
+	for _, key := range []string{"JWT_SECRET_KEY", "JWT_PREVIOUS_SECRET_KEYS"} {
+		if !written[key] {
+			lines = append(lines, key+"="+values[key])
+		}
+	}
+
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), info.Mode().Perm())
+}
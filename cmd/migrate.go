@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/yorukot/go-template/migrations"
+	db "github.com/yorukot/go-template/pkg/database"
+)
+
+// migrateCommand manages the versioned schema migrations embedded in the binary
+func migrateCommand() *command {
+	return &command{
+		name:    "migrate",
+		summary: "Manage the database schema migrations",
+		subcommands: []*command{
+			{name: "up", summary: "Apply every pending migration", run: migrateUp},
+			{name: "down", args: "[N]", summary: "Roll back the last N applied migrations (default 1)", run: migrateDown},
+			{name: "status", summary: "List migrations and whether they are applied", run: migrateStatus},
+			{name: "create", args: "NAME", summary: "Write empty up and down files for every database type", run: migrateCreate},
+			{name: "unlock", summary: "Release a lock left behind by a crashed migration", run: migrateUnlock},
+		},
+	}
+}
+
+// withMigrator connects to the database and runs fn with a migrator over the embedded migrations
+func withMigrator(fn func(ctx context.Context, migrator *db.Migrator) error) int {
+	cfg, conn, err := openDatabase()
+	if err != nil {
+		return fail(err)
+	}
+	defer db.Close(conn)
+
+	migrator, err := db.NewMigrator(conn, cfg.Database.Type, migrations.FS, cfg.Database.MigrationLockTimeout)
+	if err != nil {
+		return fail(err)
+	}
+
+	if err := fn(context.Background(), migrator); err != nil {
+		return fail(err)
+	}
+	return 0
+}
+
+func migrateUp(name string, args []string) int {
+	if code := parseFlags(newFlagSet(name, ""), args, 0); code >= 0 {
+		return code
+	}
+
+	return withMigrator(func(ctx context.Context, migrator *db.Migrator) error {
+		applied, err := migrator.Up(ctx)
+		printMigrations("Applied", applied, err)
+		return err
+	})
+}
+
+func migrateDown(name string, args []string) int {
+	flags := newFlagSet(name, "[N]")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	n := 1
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		var err error
+		if n, err = strconv.Atoi(flags.Arg(0)); err != nil || n < 1 {
+			fmt.Fprintln(os.Stderr, "N must be a positive integer")
+			return 2
+		}
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	return withMigrator(func(ctx context.Context, migrator *db.Migrator) error {
+		rolledBack, err := migrator.Down(ctx, n)
+		printMigrations("Rolled back", rolledBack, err)
+		return err
+	})
+}
+
+func migrateStatus(name string, args []string) int {
+	if code := parseFlags(newFlagSet(name, ""), args, 0); code >= 0 {
+		return code
+	}
+
+	return withMigrator(func(ctx context.Context, migrator *db.Migrator) error {
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatus(statuses)
+		return nil
+	})
+}
+
+func migrateUnlock(name string, args []string) int {
+	if code := parseFlags(newFlagSet(name, ""), args, 0); code >= 0 {
+		return code
+	}
+
+	return withMigrator(func(ctx context.Context, migrator *db.Migrator) error {
+		if err := migrator.Unlock(ctx); err != nil {
+			return err
+		}
+		fmt.Println("Migration lock released")
+		return nil
+	})
+}
+
+// migrateCreate writes the files of a new migration into the source tree
+func migrateCreate(name string, args []string) int {
+	flags := newFlagSet(name, "[-dir DIR] NAME")
+	dir := flags.String("dir", "migrations", "migrations directory")
+	if code := parseFlags(flags, args, 1); code >= 0 {
+		return code
+	}
+
+	paths, err := db.CreateMigration(*dir, flags.Arg(0), time.Now())
+	if err != nil {
+		return fail(err)
+	}
+	for _, path := range paths {
+		fmt.Println("Created", path)
+	}
+	return 0
+}
+
+// printMigrations lists the migrations a command changed before it finished or failed
+func printMigrations(action string, changed []db.Migration, err error) {
+	if len(changed) == 0 && err == nil {
+		fmt.Println("No migrations to run")
+		return
+	}
+	for _, migration := range changed {
+		fmt.Printf("%s %d_%s\n", action, migration.Version, migration.Name)
+	}
+}
+
+// printStatus prints the migration status as a table
+func printStatus(statuses []db.MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		if status.AppliedAt != nil {
+			state, appliedAt = "applied", status.AppliedAt.Format(time.RFC3339)
+		}
+		if status.Missing {
+			state = "applied, file missing"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	w.Flush()
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/app"
+	"github.com/yorukot/go-template/app/jobs"
+	"github.com/yorukot/go-template/app/routes"
+	"github.com/yorukot/go-template/pkg/config"
+	"github.com/yorukot/go-template/pkg/health"
+	"github.com/yorukot/go-template/pkg/logger"
+	"github.com/yorukot/go-template/pkg/metrics"
+	"github.com/yorukot/go-template/pkg/middleware"
+	"github.com/yorukot/go-template/pkg/shutdown"
+)
+
+// serveCommand runs the HTTP API, it is also what the binary does without arguments
+func serveCommand() *command {
+	return &command{name: "serve", summary: "Serve the HTTP API", run: runServe}
+}
+
+// runServe connects to every enabled service and serves until SIGINT or SIGTERM
+func runServe(name string, args []string) int {
+	if code := parseFlags(newFlagSet(name, ""), args, 0); code >= 0 {
+		return code
+	}
+
+	cfg := config.Get()
+	gin.SetMode(cfg.App.GinMode)
+
+	// Connect to the database and every enabled service, see config for the switches
+	a, err := app.New(context.Background(), cfg)
+	if err != nil {
+		logger.Log.Sugar().Fatalf("Failed to start application: %v", err)
+	}
+
+	root := gin.New()
+
+	root.SetTrustedProxies([]string{"127.0.0.1"})
+	root.StaticFile("/favicon.ico", "./static/favicon.ico")
+	root.Use(middleware.Tracing())
+	root.Use(middleware.RequestID())
+	root.Use(middleware.CustomLogger())
+	root.Use(middleware.Metrics())
+	root.Use(middleware.ErrorLoggerMiddleware())
+	root.Use(middleware.Recovery())
+	root.Use(middleware.CORS(cfg.CORS))
+	root.Use(middleware.SecureHeaders(middleware.DefaultSecureHeadersConfig()))
+
+	routes.HealthRoute(&root.RouterGroup)
+
+	api := root.Group("/api/v" + cfg.App.Version)
+	routes.StorageRoute(api, a)
+
+	r := api.Group("")
+	// Before CSRF, so its rejections of /auth requests are not cached either
+	r.Use(middleware.NoStoreUnder(api.BasePath() + "/auth"))
+	r.Use(middleware.CSRF())
+
+	route(r, a)
+	serveMetrics(root, cfg.Metrics)
+	startJobs(a)
+
+	printAppInfo(cfg)
+
+	root.NoRoute(func(c *gin.Context) {
+		c.JSON(404, gin.H{
+			"error":   "resource_not_found",
+			"message": "Resource not found",
+		})
+	})
+
+	serve(root, cfg.Server)
+	return 0
+}
+
+// serve runs the HTTP server until SIGINT or SIGTERM, then drains in-flight
+// requests and releases every resource registered with the shutdown package.
+// The port, timeouts and drain period come from the server section of the config.
+func serve(root *gin.Engine, cfg config.ServerConfig) {
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           root,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Log.Sugar().Infof("Listening on %s", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		logger.Log.Sugar().Fatalf("Server failed to start: %v", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	// Fail readiness first so the load balancer stops sending new requests
+	health.SetDraining(true)
+	logger.Log.Sugar().Infof("Shutdown signal received, draining for %s", cfg.DrainPeriod)
+	time.Sleep(cfg.DrainPeriod)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Log.Sugar().Errorf("Server shutdown failed: %v", err)
+	}
+	logger.Log.Info("HTTP server stopped")
+
+	// Then background workers, Redis clients, the database and telemetry
+	shutdown.Run(shutdownCtx)
+	logger.Log.Info("Shutdown complete")
+	_ = logger.Log.Sync()
+}
+
+func printAppInfo(cfg *config.Config) {
+	info := fmt.Sprintf(`
+	Gin Template API
+	Version: %s
+	Gin Version: %s
+	Domain: %s
+	`, cfg.App.Version, gin.Version, cfg.App.BaseURL)
+	logger.Log.Info(info)
+}
+
+// serveMetrics exposes the Prometheus metrics on a separate listener when
+// METRICS_ADDR is set, otherwise on /metrics protected by METRICS_TOKEN
+func serveMetrics(root *gin.Engine, cfg config.MetricsConfig) {
+	if addr := cfg.Addr; addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			logger.Log.Sugar().Infof("Serving metrics on %s", addr)
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Log.Sugar().Errorf("Metrics server failed: %v", err)
+			}
+		}()
+		shutdown.Register(shutdown.PhaseWorkers, "metrics_server", server.Shutdown)
+		return
+	}
+
+	token := cfg.Token
+	if token == "" {
+		logger.Log.Warn("METRICS_ADDR and METRICS_TOKEN are not set, metrics endpoint disabled")
+		return
+	}
+	root.GET("/metrics", middleware.MetricsAuth(token), gin.WrapH(metrics.Handler()))
+}
+
+// startJobs runs the background jobs, they stop with the shutdown hooks
+func startJobs(a *app.App) {
+	purger := &jobs.UserPurger{
+		Tx:       a.Tx,
+		Users:    a.Users,
+		Sessions: a.Sessions,
+		Public:   a.Public,
+		Private:  a.Private,
+		Config:   a.Config.Account,
+	}
+	jobs.Every("purge_deleted_users", a.Config.Account.PurgeInterval, purger.Job())
+
+	exporter := &jobs.Exporter{
+		Exports:  a.Exports,
+		Users:    a.Users,
+		Sessions: a.Sessions,
+		Storage:  a.Private,
+		Config:   a.Config.Export,
+	}
+	jobs.Every("build_user_exports", a.Config.Export.WorkerInterval, exporter.Job())
+
+	sweeper := &jobs.UploadSweeper{
+		Uploads: a.Uploads,
+		Storage: a.Private,
+	}
+	jobs.Every("sweep_uploads", a.Config.Upload.SweepInterval, sweeper.Job())
+}
+
+func route(r *gin.RouterGroup, a *app.App) {
+	routes.AuthRoute(r, a)
+	routes.UserRoute(r, a)
+	routes.UploadRoute(r, a)
+	routes.AdminRoute(r, a)
+}
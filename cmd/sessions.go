@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yorukot/go-template/app/repository"
+	db "github.com/yorukot/go-template/pkg/database"
+)
+
+// sessionsCommand manages the refresh token sessions
+func sessionsCommand() *command {
+	return &command{
+		name:    "sessions",
+		summary: "Manage login sessions",
+		subcommands: []*command{
+			{name: "purge-expired", summary: "Delete the sessions whose refresh token expired", run: sessionsPurgeExpired},
+		},
+	}
+}
+
+func sessionsPurgeExpired(name string, args []string) int {
+	if code := parseFlags(newFlagSet(name, ""), args, 0); code >= 0 {
+		return code
+	}
+
+	_, conn, err := openDatabase()
+	if err != nil {
+		return fail(err)
+	}
+	defer db.Close(conn)
+
+	sessions := repository.NewGormSessionRepository(conn)
+	deleted, err := sessions.DeleteExpired(context.Background(), time.Now())
+	if err != nil {
+		return fail(fmt.Errorf("failed to delete expired sessions: %w", err))
+	}
+
+	fmt.Printf("Deleted %d expired sessions\n", deleted)
+	return 0
+}
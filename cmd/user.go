@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/yorukot/go-template/app/controllers/auth"
+	"github.com/yorukot/go-template/app/jobs"
+	"github.com/yorukot/go-template/app/models"
+	"github.com/yorukot/go-template/app/repository"
+	db "github.com/yorukot/go-template/pkg/database"
+	"github.com/yorukot/go-template/pkg/encryption"
+	store "github.com/yorukot/go-template/pkg/s3"
+	"github.com/yorukot/go-template/pkg/storage"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+// generatedPasswordLength is the length of the passwords generated when none is given
+const generatedPasswordLength = 20
+
+// userCommand manages user accounts
+func userCommand() *command {
+	return &command{
+		name:    "user",
+		summary: "Manage user accounts",
+		subcommands: []*command{
+			{name: "create", args: "-email EMAIL -name NAME [-admin] [-password-stdin]", summary: "Create a user, optionally an administrator", run: userCreate},
+			{name: "reset-password", args: "-email EMAIL [-password-stdin]", summary: "Set a new password and log the user out everywhere", run: userResetPassword},
+			{name: "disable", args: "-email EMAIL", summary: "Block the login of a user and log them out everywhere", run: userDisable},
+			{name: "enable", args: "-email EMAIL", summary: "Allow a disabled user to log in again", run: userEnable},
+			{name: "purge-deleted", summary: "Remove the deleted accounts whose grace period is over", run: userPurgeDeleted},
+		},
+	}
+}
+
+// userRepositories holds the repositories the user commands work with
+type userRepositories struct {
+	users    repository.UserRepository
+	sessions repository.SessionRepository
+}
+
+// withUserRepositories connects to the database and runs fn with the repositories
+func withUserRepositories(fn func(ctx context.Context, repos userRepositories) error) int {
+	_, conn, err := openDatabase()
+	if err != nil {
+		return fail(err)
+	}
+	defer db.Close(conn)
+
+	repos := userRepositories{
+		users:    repository.NewGormUserRepository(conn),
+		sessions: repository.NewGormSessionRepository(conn),
+	}
+	if err := fn(context.Background(), repos); err != nil {
+		return fail(err)
+	}
+	return 0
+}
+
+func userCreate(name string, args []string) int {
+	flags := newFlagSet(name, "-email EMAIL -name NAME [-admin] [-password-stdin]")
+	email := flags.String("email", "", "email of the user")
+	displayName := flags.String("name", "", "display name of the user")
+	admin := flags.Bool("admin", false, "grant administrator rights")
+	passwordStdin := flags.Bool("password-stdin", false, "read the password from the first line of stdin instead of generating one")
+	if code := parseFlags(flags, args, 0); code >= 0 {
+		return code
+	}
+
+	password, generated, err := readPassword(*passwordStdin)
+	if err != nil {
+		return fail(err)
+	}
+
+	// The same rules as the signup endpoint
+	request := auth.EmailAuthRequest{DisplayName: *displayName, Email: *email, Password: password}
+	if err := binding.Validator.ValidateStruct(&request); err != nil {
+		return fail(fmt.Errorf("invalid user: %w", err))
+	}
+
+	return withUserRepositories(func(ctx context.Context, repos userRepositories) error {
+		hashedPassword, err := encryption.HashPassword(request.Password)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+
+		user := models.User{
+			ID:          encryption.GenerateID(),
+			DisplayName: request.DisplayName,
+			Email:       request.Email,
+			Password:    hashedPassword,
+			IsAdmin:     *admin,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+		if err := repos.users.Create(ctx, &user); errors.Is(err, repository.ErrConflict) {
+			return fmt.Errorf("email %s is already used", user.Email)
+		} else if err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		role := "user"
+		if user.IsAdmin {
+			role = "administrator"
+		}
+		fmt.Printf("Created %s %s with ID %d\n", role, user.Email, user.ID)
+		if generated {
+			fmt.Printf("Generated password: %s\n", password)
+		}
+		return nil
+	})
+}
+
+func userResetPassword(name string, args []string) int {
+	flags := newFlagSet(name, "-email EMAIL [-password-stdin]")
+	email := flags.String("email", "", "email of the user")
+	passwordStdin := flags.Bool("password-stdin", false, "read the password from the first line of stdin instead of generating one")
+	if code := parseFlags(flags, args, 0); code >= 0 {
+		return code
+	}
+
+	password, generated, err := readPassword(*passwordStdin)
+	if err != nil {
+		return fail(err)
+	}
+
+	// The same rules as the login endpoint
+	request := auth.EmailLoginRequest{Email: *email, Password: password}
+	if err := binding.Validator.ValidateStruct(&request); err != nil || request.Email == "" {
+		return fail(fmt.Errorf("invalid email or password: %v", err))
+	}
+
+	return withUserRepositories(func(ctx context.Context, repos userRepositories) error {
+		user, err := getUserByEmail(ctx, repos.users, request.Email)
+		if err != nil {
+			return err
+		}
+
+		if user.Password, err = encryption.HashPassword(request.Password); err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+		user.UpdatedAt = time.Now()
+		if err := repos.users.Update(ctx, &user); err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+
+		revoked, err := repos.sessions.DeleteByUserID(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+
+		fmt.Printf("Password of %s reset, %d sessions revoked\n", user.Email, revoked)
+		if generated {
+			fmt.Printf("Generated password: %s\n", password)
+		}
+		return nil
+	})
+}
+
+func userDisable(name string, args []string) int {
+	flags := newFlagSet(name, "-email EMAIL")
+	email := flags.String("email", "", "email of the user")
+	if code := parseFlags(flags, args, 0); code >= 0 {
+		return code
+	}
+
+	return withUserRepositories(func(ctx context.Context, repos userRepositories) error {
+		user, err := getUserByEmail(ctx, repos.users, *email)
+		if err != nil {
+			return err
+		}
+
+		if user.DisabledAt == nil {
+			now := time.Now()
+			user.DisabledAt, user.UpdatedAt = &now, now
+			if err := repos.users.Update(ctx, &user); err != nil {
+				return fmt.Errorf("failed to update user: %w", err)
+			}
+		}
+
+		// Revoke the refresh tokens, access tokens expire on their own
+		revoked, err := repos.sessions.DeleteByUserID(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+
+		fmt.Printf("Disabled %s, %d sessions revoked\n", user.Email, revoked)
+		return nil
+	})
+}
+
+func userEnable(name string, args []string) int {
+	flags := newFlagSet(name, "-email EMAIL")
+	email := flags.String("email", "", "email of the user")
+	if code := parseFlags(flags, args, 0); code >= 0 {
+		return code
+	}
+
+	return withUserRepositories(func(ctx context.Context, repos userRepositories) error {
+		user, err := getUserByEmail(ctx, repos.users, *email)
+		if err != nil {
+			return err
+		}
+
+		if user.DisabledAt != nil {
+			user.DisabledAt, user.UpdatedAt = nil, time.Now()
+			if err := repos.users.Update(ctx, &user); err != nil {
+				return fmt.Errorf("failed to update user: %w", err)
+			}
+		}
+
+		fmt.Printf("Enabled %s\n", user.Email)
+		return nil
+	})
+}
+
+// userPurgeDeleted runs the purge the server runs every ACCOUNT_PURGE_INTERVAL
+func userPurgeDeleted(name string, args []string) int {
+	if code := parseFlags(newFlagSet(name, ""), args, 0); code >= 0 {
+		return code
+	}
+
+	cfg, conn, err := openDatabase()
+	if err != nil {
+		return fail(err)
+	}
+	defer db.Close(conn)
+
+	ctx := context.Background()
+	purger := &jobs.UserPurger{
+		Tx:       repository.NewGormTransactor(conn),
+		Users:    repository.NewGormUserRepository(conn),
+		Sessions: repository.NewGormSessionRepository(conn),
+		Config:   cfg.Account,
+	}
+
+	// Uploaded avatars, export archives and uploads are deleted with the accounts
+	var s3Store *store.Store
+	if cfg.S3.Enabled {
+		if s3Store, err = store.New(ctx, cfg.S3); err != nil {
+			return fail(err)
+		}
+	}
+	if purger.Public, purger.Private, err = storage.Open(cfg, s3Store, utils.BackendURL); err != nil {
+		return fail(err)
+	}
+
+	purged, err := purger.Run(ctx)
+	fmt.Printf("Purged %d deleted accounts\n", purged)
+	if err != nil {
+		return fail(err)
+	}
+	return 0
+}
+
+// getUserByEmail returns the user or an error naming the missing email
+func getUserByEmail(ctx context.Context, users repository.UserRepository, email string) (models.User, error) {
+	if email == "" {
+		return models.User{}, errors.New("-email is required")
+	}
+
+	user, err := users.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.User{}, fmt.Errorf("no user with email %s", email)
+	} else if err != nil {
+		return models.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// readPassword reads the first line of stdin, or generates a password that
+// the command prints once. Passwords are never taken as flags, which would
+// leave them in the shell history and the process list.
+func readPassword(fromStdin bool) (password string, generated bool, err error) {
+	if !fromStdin {
+		password, err = encryption.RandStringRunes(generatedPasswordLength, true)
+		return password, true, err
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", false, fmt.Errorf("failed to read password from stdin: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), false, nil
+}
@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned SQL migrations, one directory per
+// database type. Add new files with `migrate create <name>`.
+package migrations
+
+import "embed"
+
+// FS holds the migration files of every database type
+//
+//go:embed postgres/*.sql mysql/*.sql sqlite/*.sql
+var FS embed.FS
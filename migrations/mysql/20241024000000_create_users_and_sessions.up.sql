@@ -0,0 +1,25 @@
+-- Baseline schema, IF NOT EXISTS lets databases created by AutoMigrate adopt it
+CREATE TABLE IF NOT EXISTS users (
+    id           BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+    avatar       TEXT,
+    display_name VARCHAR(255) NOT NULL,
+    email        VARCHAR(320) NOT NULL,
+    password     TEXT,
+    is_admin     BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at   DATETIME(3) NOT NULL,
+    updated_at   DATETIME(3) NOT NULL,
+    CONSTRAINT uni_users_email UNIQUE (email)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- The secret key is ASCII, which keeps its unique index under the InnoDB key length limit
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+    secret_key VARCHAR(1024) CHARACTER SET ascii COLLATE ascii_bin NOT NULL,
+    user_agent VARCHAR(512),
+    user_id    BIGINT UNSIGNED NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    created_at DATETIME(3),
+    UNIQUE KEY idx_sessions_secret_key (secret_key),
+    KEY idx_sessions_user_id (user_id),
+    CONSTRAINT fk_sessions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS reserved_emails;
+DROP INDEX idx_users_deleted_at ON users;
+ALTER TABLE users DROP COLUMN deleted_at;
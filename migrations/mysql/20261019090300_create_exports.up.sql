@@ -0,0 +1,15 @@
+-- Personal data exports, also the queue of the export job
+CREATE TABLE IF NOT EXISTS exports (
+    id           BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+    user_id      BIGINT UNSIGNED NOT NULL,
+    status       VARCHAR(16) NOT NULL,
+    object_key   VARCHAR(255),
+    error        VARCHAR(512),
+    created_at   DATETIME(3) NULL,
+    started_at   DATETIME(3) NULL,
+    completed_at DATETIME(3) NULL,
+    expires_at   DATETIME(3) NULL,
+    INDEX idx_exports_user_id (user_id),
+    INDEX idx_exports_status (status),
+    CONSTRAINT fk_exports_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,15 @@
+-- Files uploaded straight to the private storage through signed URLs
+CREATE TABLE IF NOT EXISTS uploads (
+    id           BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+    user_id      BIGINT UNSIGNED NOT NULL,
+    status       VARCHAR(16) NOT NULL,
+    object_key   VARCHAR(255) NOT NULL,
+    content_type VARCHAR(64) NOT NULL,
+    size         BIGINT NOT NULL,
+    created_at   DATETIME(3) NULL,
+    expires_at   DATETIME(3) NOT NULL,
+    completed_at DATETIME(3) NULL,
+    INDEX idx_uploads_user_id (user_id),
+    INDEX idx_uploads_status (status),
+    CONSTRAINT fk_uploads_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
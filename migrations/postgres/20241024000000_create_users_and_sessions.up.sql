@@ -0,0 +1,25 @@
+-- Baseline schema, IF NOT EXISTS lets databases created by AutoMigrate adopt it
+CREATE TABLE IF NOT EXISTS users (
+    id           BIGINT PRIMARY KEY,
+    avatar       TEXT,
+    display_name TEXT NOT NULL,
+    email        VARCHAR(320) NOT NULL,
+    password     TEXT,
+    is_admin     BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at   TIMESTAMPTZ NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL,
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id BIGINT PRIMARY KEY,
+    secret_key VARCHAR(1024) NOT NULL,
+    user_agent VARCHAR(512),
+    user_id    BIGINT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ,
+    CONSTRAINT fk_sessions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_secret_key ON sessions (secret_key);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
@@ -0,0 +1,2 @@
+-- Disabled users keep their data but can no longer log in
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+-- Lets "sessions purge-expired" find expired sessions without a full scan
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions (expires_at);
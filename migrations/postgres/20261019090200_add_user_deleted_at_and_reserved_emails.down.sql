@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS reserved_emails;
+DROP INDEX IF EXISTS idx_users_deleted_at;
+ALTER TABLE users DROP COLUMN deleted_at;
@@ -0,0 +1,16 @@
+-- Personal data exports, also the queue of the export job
+CREATE TABLE IF NOT EXISTS exports (
+    id           BIGINT PRIMARY KEY,
+    user_id      BIGINT NOT NULL,
+    status       VARCHAR(16) NOT NULL,
+    object_key   VARCHAR(255),
+    error        VARCHAR(512),
+    created_at   TIMESTAMPTZ,
+    started_at   TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at   TIMESTAMPTZ,
+    CONSTRAINT fk_exports_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_exports_user_id ON exports (user_id);
+CREATE INDEX IF NOT EXISTS idx_exports_status ON exports (status);
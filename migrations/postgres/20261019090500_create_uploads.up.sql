@@ -0,0 +1,16 @@
+-- Files uploaded straight to the private storage through signed URLs
+CREATE TABLE IF NOT EXISTS uploads (
+    id           BIGINT PRIMARY KEY,
+    user_id      BIGINT NOT NULL,
+    status       VARCHAR(16) NOT NULL,
+    object_key   VARCHAR(255) NOT NULL,
+    content_type VARCHAR(64) NOT NULL,
+    size         BIGINT NOT NULL,
+    created_at   TIMESTAMPTZ,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ,
+    CONSTRAINT fk_uploads_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_uploads_user_id ON uploads (user_id);
+CREATE INDEX IF NOT EXISTS idx_uploads_status ON uploads (status);
@@ -0,0 +1,25 @@
+-- Baseline schema, IF NOT EXISTS lets databases created by AutoMigrate adopt it
+CREATE TABLE IF NOT EXISTS users (
+    id           INTEGER PRIMARY KEY,
+    avatar       TEXT,
+    display_name TEXT NOT NULL,
+    email        TEXT NOT NULL,
+    password     TEXT,
+    is_admin     NUMERIC NOT NULL DEFAULT false,
+    created_at   DATETIME NOT NULL,
+    updated_at   DATETIME NOT NULL,
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id INTEGER PRIMARY KEY,
+    secret_key TEXT NOT NULL,
+    user_agent TEXT,
+    user_id    INTEGER NOT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME,
+    CONSTRAINT fk_sessions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_secret_key ON sessions (secret_key);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
@@ -0,0 +1,9 @@
+-- Deleted users can restore their account by logging in until they are purged
+ALTER TABLE users ADD COLUMN deleted_at DATETIME;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+-- Hashes of the emails of purged accounts, see ACCOUNT_RESERVE_DELETED_EMAILS
+CREATE TABLE IF NOT EXISTS reserved_emails (
+    email_hash TEXT PRIMARY KEY,
+    created_at DATETIME
+);
@@ -0,0 +1,16 @@
+-- Personal data exports, also the queue of the export job
+CREATE TABLE IF NOT EXISTS exports (
+    id           INTEGER PRIMARY KEY,
+    user_id      INTEGER NOT NULL,
+    status       TEXT NOT NULL,
+    object_key   TEXT,
+    error        TEXT,
+    created_at   DATETIME,
+    started_at   DATETIME,
+    completed_at DATETIME,
+    expires_at   DATETIME,
+    CONSTRAINT fk_exports_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_exports_user_id ON exports (user_id);
+CREATE INDEX IF NOT EXISTS idx_exports_status ON exports (status);
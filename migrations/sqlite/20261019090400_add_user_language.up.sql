@@ -0,0 +1,2 @@
+-- Language preference of the user, one of utils.LangList
+ALTER TABLE users ADD COLUMN language TEXT NOT NULL DEFAULT 'en';
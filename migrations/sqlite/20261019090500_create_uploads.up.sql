@@ -0,0 +1,16 @@
+-- Files uploaded straight to the private storage through signed URLs
+CREATE TABLE IF NOT EXISTS uploads (
+    id           INTEGER PRIMARY KEY,
+    user_id      INTEGER NOT NULL,
+    status       TEXT NOT NULL,
+    object_key   TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size         INTEGER NOT NULL,
+    created_at   DATETIME,
+    expires_at   DATETIME NOT NULL,
+    completed_at DATETIME,
+    CONSTRAINT fk_uploads_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_uploads_user_id ON uploads (user_id);
+CREATE INDEX IF NOT EXISTS idx_uploads_status ON uploads (status);
@@ -2,59 +2,91 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/yorukot/go-template/pkg/config"
 	"github.com/yorukot/go-template/pkg/logger"
+	"github.com/yorukot/go-template/pkg/metrics"
+	"github.com/yorukot/go-template/pkg/tracing"
 )
 
-var (
-	RedisClient  *redis.Client
-	RedisLimiter *redis.Client
-)
+// Cache holds the Redis clients, the limiter client uses its own database so
+// rate limit keys never collide with cached data
+type Cache struct {
+	Client  *redis.Client
+	Limiter *redis.Client
+}
 
-// InitializeRedis initializes the Redis client.
-func init() {
+// New connects both Redis clients and registers their metrics and tracing.
+// The caller owns the returned clients and must Close them.
+func New(cfg config.CacheConfig) (*Cache, error) {
 	// Read Redis database number.
 	limiterDB := 1
 	normalDB := 0
 
-	host := os.Getenv("CACHE_HOST")
-	port := os.Getenv("CACHE_PORT")
-	if host == "" || port == "" {
-		logger.Log.Fatal("CACHE_HOST or CACHE_PORT is not set")
+	if cfg.Host == "" || cfg.Port == "" {
+		return nil, errors.New("CACHE_HOST or CACHE_PORT is not set")
 	}
 
-	url := fmt.Sprintf("%s:%s", host, port)
+	url := fmt.Sprintf("%s:%s", cfg.Host, cfg.Port)
 
 	// Set Redis options.
 	options := &redis.Options{
 		Addr:         url,
-		Password:     os.Getenv("CACHE_PASSWORD"),
+		Password:     cfg.Password,
 		DB:           normalDB,
 		PoolSize:     10,
 		MinIdleConns: 1,
 	}
 
-	RedisClient = redis.NewClient(options)
+	c := &Cache{Client: redis.NewClient(options)}
 
 	options = &redis.Options{
 		Addr:         url,
-		Password:     os.Getenv("CACHE_PASSWORD"),
+		Password:     cfg.Password,
 		DB:           limiterDB,
 		PoolSize:     10,
 		MinIdleConns: 1,
 	}
 
-	RedisLimiter = redis.NewClient(options)
+	c.Limiter = redis.NewClient(options)
 
 	// Test connection
-	if err := RedisClient.Ping(context.Background()).Err(); err != nil {
-		logger.Log.Fatal(fmt.Sprintf("Failed to connect to Redis: %v", err))
+	if err := c.Ping(context.Background()); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	// Export connection pool stats
+	if err := metrics.RegisterRedis(c.Client, "cache"); err != nil {
+		logger.Log.Warn(fmt.Sprintf("Failed to register Redis metrics: %v", err))
+	}
+	if err := metrics.RegisterRedis(c.Limiter, "limiter"); err != nil {
+		logger.Log.Warn(fmt.Sprintf("Failed to register Redis metrics: %v", err))
+	}
+
+	// Create a span for every command
+	if err := tracing.RegisterRedis(c.Client); err != nil {
+		logger.Log.Warn(fmt.Sprintf("Failed to register Redis tracing: %v", err))
+	}
+	if err := tracing.RegisterRedis(c.Limiter); err != nil {
+		logger.Log.Warn(fmt.Sprintf("Failed to register Redis tracing: %v", err))
 	}
 
-	if err := RedisLimiter.Ping(context.Background()).Err(); err != nil {
-		logger.Log.Fatal(fmt.Sprintf("Failed to connect to Redis: %v", err))
+	return c, nil
+}
+
+// Ping checks that both Redis clients are reachable
+func (c *Cache) Ping(ctx context.Context) error {
+	if err := c.Client.Ping(ctx).Err(); err != nil {
+		return err
 	}
+	return c.Limiter.Ping(ctx).Err()
+}
+
+// Close closes both Redis clients
+func (c *Cache) Close() error {
+	return errors.Join(c.Client.Close(), c.Limiter.Close())
 }
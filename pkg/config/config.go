@@ -0,0 +1,228 @@
+package config
+
+import (
+	"time"
+)
+
+// Config is the typed application configuration. Every field is loaded from
+// its `env` variable, then from the optional CONFIG_FILE using the `yaml`
+// keys, then from the `default` tag. Fields tagged `secret` are redacted in
+// Dump, `required` fields must be set and `unit` gives the unit of bare
+// numbers for durations (for example "15" with unit "s" is 15 seconds).
+type Config struct {
+	App      AppConfig      `yaml:"app"`
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	Cache    CacheConfig    `yaml:"cache"`
+	S3       S3Config       `yaml:"s3"`
+	Storage  StorageConfig  `yaml:"storage"`
+	Argon2   Argon2Config   `yaml:"argon2"`
+	Cookie   CookieConfig   `yaml:"cookie"`
+	Account  AccountConfig  `yaml:"account"`
+	Export   ExportConfig   `yaml:"export"`
+	Avatar   AvatarConfig   `yaml:"avatar"`
+	Upload   UploadConfig   `yaml:"upload"`
+	OAuth    OAuthConfig    `yaml:"oauth"`
+	SMTP     SMTPConfig     `yaml:"smtp"`
+	Log      LogConfig      `yaml:"log"`
+	CORS     CORSConfig     `yaml:"cors"`
+	Security SecurityConfig `yaml:"security"`
+	Health   HealthConfig   `yaml:"health"`
+	Metrics  MetricsConfig  `yaml:"metrics"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+	Gitea    GiteaConfig    `yaml:"gitea"`
+}
+
+// AppConfig holds the general application settings
+type AppConfig struct {
+	GinMode   string `yaml:"gin_mode" env:"GIN_MODE" default:"release"`
+	Version   string `yaml:"version" env:"VERSION" default:"1"`
+	MachineID int    `yaml:"machine_id" env:"MACHINE_ID" required:"true"`
+	BaseURL   string `yaml:"base_url" env:"BASE_URL" required:"true"`
+}
+
+// ServerConfig holds the HTTP server and shutdown settings
+type ServerConfig struct {
+	Port            string        `yaml:"port" env:"PORT" default:"8080"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT" default:"15" unit:"s"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" default:"30" unit:"s"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" default:"120" unit:"s"`
+	DrainPeriod     time.Duration `yaml:"drain_period" env:"SHUTDOWN_DRAIN_PERIOD" default:"5" unit:"s"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"30" unit:"s"`
+}
+
+// DatabaseConfig holds the database connection settings
+type DatabaseConfig struct {
+	Type              string        `yaml:"type" env:"DATABASE_TYPE" default:"postgres"`
+	URL               string        `yaml:"url" env:"DATABASE_URL" secret:"true"` // replaces the connection fields below, its scheme sets Type
+	Host              string        `yaml:"host" env:"DATABASE_HOST"`
+	Port              string        `yaml:"port" env:"DATABASE_PORT"`
+	User              string        `yaml:"user" env:"DATABASE_USER"`
+	Password          string        `yaml:"password" env:"DATABASE_PASSWORD" secret:"true"`
+	DBName            string        `yaml:"dbname" env:"DATABASE_DBNAME"`
+	SSLMode           string        `yaml:"sslmode" env:"DATABASE_SSLMODE" default:"disable"`
+	Charset           string        `yaml:"charset" env:"DATABASE_CHARSET" default:"utf8mb4"`
+	TLS               string        `yaml:"tls" env:"DATABASE_TLS"` // MySQL TLS mode
+	TLSCA             string        `yaml:"tls_ca" env:"DATABASE_TLS_CA"`
+	TLSCert           string        `yaml:"tls_cert" env:"DATABASE_TLS_CERT"`
+	TLSKey            string        `yaml:"tls_key" env:"DATABASE_TLS_KEY"`
+	Path              string        `yaml:"path" env:"DATABASE_PATH" default:"database.db"`
+	SQLiteJournalMode string        `yaml:"sqlite_journal_mode" env:"DATABASE_SQLITE_JOURNAL_MODE" default:"WAL"`
+	SQLiteBusyTimeout time.Duration `yaml:"sqlite_busy_timeout" env:"DATABASE_SQLITE_BUSY_TIMEOUT" default:"5000" unit:"ms"`
+	MaxIdleConns      int           `yaml:"max_idle_conns" env:"DATABASE_MAX_IDLE_CONNS" default:"10"`
+	MaxOpenConns      int           `yaml:"max_open_conns" env:"DATABASE_MAX_OPEN_CONNS" default:"100"`
+	ConnMaxLifetime   time.Duration `yaml:"conn_max_lifetime" env:"DATABASE_CONN_MAX_LIFETIME" default:"30" unit:"m"`
+	ConnectTimeout    time.Duration `yaml:"connect_timeout" env:"DATABASE_CONNECT_TIMEOUT" default:"60" unit:"s"` // retry deadline at startup
+	// Replicas are the URLs of read replicas, reads are spread over the healthy ones
+	Replicas             []string      `yaml:"replicas" env:"DATABASE_REPLICAS" secret:"true"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" env:"DATABASE_REPLICA_CHECK_INTERVAL" default:"10" unit:"s"`
+	// AutoMigrate syncs the schema from the models at startup, for development only
+	AutoMigrate          bool          `yaml:"auto_migrate" env:"DATABASE_AUTO_MIGRATE" default:"false"`
+	MigrationLockTimeout time.Duration `yaml:"migration_lock_timeout" env:"DATABASE_MIGRATION_LOCK_TIMEOUT" default:"60" unit:"s"`
+}
+
+// CacheConfig holds the Redis settings
+type CacheConfig struct {
+	Enabled  bool   `yaml:"enabled" env:"CACHE_ENABLED" default:"false"`
+	Host     string `yaml:"host" env:"CACHE_HOST"`
+	Port     string `yaml:"port" env:"CACHE_PORT" default:"6379"`
+	Password string `yaml:"password" env:"CACHE_PASSWORD" secret:"true"`
+}
+
+// S3Config holds the S3 compatible object storage settings
+type S3Config struct {
+	Enabled             bool   `yaml:"enabled" env:"S3_ENABLED" default:"false"`
+	Endpoint            string `yaml:"endpoint" env:"S3_ENDPOINT"`
+	AccessKeyID         string `yaml:"access_key_id" env:"S3_ACCESS_KEY_ID"`
+	SecretKey           string `yaml:"secret_key" env:"S3_SECRET_KEY" secret:"true"`
+	PathStyle           bool   `yaml:"path_style" env:"S3_PATH_STYLE" default:"false"`
+	InsecureSkipVerify  bool   `yaml:"insecure_skip_verify" env:"S3_INSECURE_SKIP_VERIFY" default:"false"` // for self-signed development endpoints only
+	StaticBucket        string `yaml:"static_bucket" env:"S3_STATIC_BUCKET" default:"static"`
+	StaticBucketBaseURL string `yaml:"static_bucket_baseurl" env:"S3_STATIC_BUCKET_BASEURL"`
+	PrivateBucket       string `yaml:"private_bucket" env:"S3_PRIVATE_BUCKET" default:"private"` // read through signed URLs
+}
+
+// StorageConfig selects where the files are stored
+type StorageConfig struct {
+	Backend    string `yaml:"backend" env:"STORAGE_BACKEND"` // s3, local or memory, s3 when S3_ENABLED and local otherwise by default
+	LocalDir   string `yaml:"local_dir" env:"STORAGE_LOCAL_DIR" default:"data"`
+	SigningKey string `yaml:"signing_key" env:"STORAGE_SIGNING_KEY" secret:"true"` // signs the links of the local and memory backends, JWT_SECRET_KEY by default
+}
+
+// Argon2Config holds the password hashing parameters
+type Argon2Config struct {
+	Memory      uint32 `yaml:"memory" env:"ARGON2_MEMORY" default:"65536"`
+	Iterations  uint32 `yaml:"iterations" env:"ARGON2_ITERATIONS" default:"3"`
+	Parallelism uint8  `yaml:"parallelism" env:"ARGON2_PARALLELISM" default:"4"`
+}
+
+// CookieConfig holds the session cookie and JWT settings
+type CookieConfig struct {
+	JWTSecretKey          string   `yaml:"jwt_secret_key" env:"JWT_SECRET_KEY" required:"true" secret:"true"`
+	JWTPreviousSecretKeys []string `yaml:"jwt_previous_secret_keys" env:"JWT_PREVIOUS_SECRET_KEYS" secret:"true"` // verify only, see "keys rotate"
+	Domain                string   `yaml:"domain" env:"COOKIE_DOMAIN"`
+	Path                  string   `yaml:"path" env:"COOKIE_PATH" default:"/"`
+	SameSite              string   `yaml:"same_site" env:"COOKIE_SAME_SITE" default:"lax"`
+	RefreshTokenExpires   int      `yaml:"refresh_token_expires" env:"COOKIE_REFRESH_TOKEN_EXPIRES" default:"60"` // days
+	AccessTokenExpires    int      `yaml:"access_token_expires" env:"COOKIE_ACCESS_TOKEN_EXPIRES" default:"15"`   // minutes
+}
+
+// AccountConfig holds the account deletion settings
+type AccountConfig struct {
+	DeletionGracePeriod  time.Duration `yaml:"deletion_grace_period" env:"ACCOUNT_DELETION_GRACE_PERIOD" default:"30" unit:"d"` // logging in restores the account until then
+	PurgeInterval        time.Duration `yaml:"purge_interval" env:"ACCOUNT_PURGE_INTERVAL" default:"1" unit:"h"`
+	ReserveDeletedEmails bool          `yaml:"reserve_deleted_emails" env:"ACCOUNT_RESERVE_DELETED_EMAILS" default:"false"` // block signups with the email of a purged account
+}
+
+// ExportConfig holds the personal data export settings
+type ExportConfig struct {
+	Interval       time.Duration `yaml:"interval" env:"USER_EXPORT_INTERVAL" default:"24" unit:"h"` // per user rate limit
+	LinkTTL        time.Duration `yaml:"link_ttl" env:"USER_EXPORT_LINK_TTL" default:"24" unit:"h"`
+	WorkerInterval time.Duration `yaml:"worker_interval" env:"USER_EXPORT_WORKER_INTERVAL" default:"10" unit:"s"`
+}
+
+// AvatarConfig holds the avatar upload limits
+type AvatarConfig struct {
+	MaxSize      int64 `yaml:"max_size" env:"AVATAR_MAX_SIZE" default:"5242880"`        // bytes
+	MaxDimension int   `yaml:"max_dimension" env:"AVATAR_MAX_DIMENSION" default:"4096"` // pixels, width and height
+}
+
+// UploadConfig holds the settings of the uploads made through signed URLs
+type UploadConfig struct {
+	MaxSize       int64         `yaml:"max_size" env:"UPLOAD_MAX_SIZE" default:"10485760"` // bytes
+	URLTTL        time.Duration `yaml:"url_ttl" env:"UPLOAD_URL_TTL" default:"15" unit:"m"`
+	SweepInterval time.Duration `yaml:"sweep_interval" env:"UPLOAD_SWEEP_INTERVAL" default:"10" unit:"m"`
+}
+
+// OAuthConfig holds the OAuth provider credentials
+type OAuthConfig struct {
+	Enabled            bool   `yaml:"enabled" env:"OAUTH_ENABLED" default:"false"`
+	SessionSecret      string `yaml:"session_secret" env:"SESSION_SECRET" secret:"true"`
+	GoogleClientID     string `yaml:"google_client_id" env:"GOOGLE_CLIENT_ID"`
+	GoogleClientSecret string `yaml:"google_client_secret" env:"GOOGLE_CLIENT_SECRET" secret:"true"`
+	GithubClientID     string `yaml:"github_client_id" env:"GITHUB_CLIENT_ID"`
+	GithubClientSecret string `yaml:"github_client_secret" env:"GITHUB_CLIENT_SECRET" secret:"true"`
+	GitlabClientID     string `yaml:"gitlab_client_id" env:"GITLAB_CLIENT_ID"`
+	GitlabClientSecret string `yaml:"gitlab_client_secret" env:"GITLAB_CLIENT_SECRET" secret:"true"`
+}
+
+// SMTPConfig holds the outgoing email settings
+type SMTPConfig struct {
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     int    `yaml:"port" env:"SMTP_PORT" default:"587"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Key      string `yaml:"key" env:"SMTP_KEY" secret:"true"`
+	From     string `yaml:"from" env:"SMTP_FROM"`
+}
+
+// LogConfig holds the logger settings, empty level and output follow GIN_MODE
+type LogConfig struct {
+	Level              string `yaml:"level" env:"LOG_LEVEL"`
+	Output             string `yaml:"output" env:"LOG_OUTPUT"`
+	FilePath           string `yaml:"file_path" env:"LOG_FILE_PATH" default:"logs/app.log"`
+	FileMaxSize        int    `yaml:"file_max_size" env:"LOG_FILE_MAX_SIZE" default:"100"`
+	FileMaxAge         int    `yaml:"file_max_age" env:"LOG_FILE_MAX_AGE" default:"30"`
+	FileMaxBackups     int    `yaml:"file_max_backups" env:"LOG_FILE_MAX_BACKUPS" default:"10"`
+	SamplingInitial    int    `yaml:"sampling_initial" env:"LOG_SAMPLING_INITIAL" default:"0"`
+	SamplingThereafter int    `yaml:"sampling_thereafter" env:"LOG_SAMPLING_THEREAFTER" default:"100"`
+}
+
+// CORSConfig holds the cross-origin policy, empty allowed origins means BASE_URL only
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string      `yaml:"allowed_methods" env:"CORS_ALLOWED_METHODS" default:"GET,POST,PUT,PATCH,DELETE,OPTIONS"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS" default:"Origin,Content-Type,Accept,Authorization,X-CSRF-Token,X-Request-ID"`
+	ExposedHeaders   []string      `yaml:"exposed_headers" env:"CORS_EXPOSED_HEADERS" default:"X-Request-ID"`
+	AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" default:"true"`
+	MaxAge           time.Duration `yaml:"max_age" env:"CORS_MAX_AGE" default:"600" unit:"s"`
+}
+
+// SecurityConfig holds the security header settings
+type SecurityConfig struct {
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age" env:"SECURITY_HSTS_MAX_AGE" default:"63072000" unit:"s"`
+	ContentSecurityPolicy string        `yaml:"csp" env:"SECURITY_CSP" default:"default-src 'none'; frame-ancestors 'none'"`
+}
+
+// HealthConfig holds the readiness check settings
+type HealthConfig struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"2000" unit:"ms"`
+}
+
+// MetricsConfig holds the Prometheus endpoint settings
+type MetricsConfig struct {
+	Addr  string `yaml:"addr" env:"METRICS_ADDR"`
+	Token string `yaml:"token" env:"METRICS_TOKEN" secret:"true"`
+}
+
+// TracingConfig holds the OpenTelemetry settings, the OTLP exporter reads its own OTEL_EXPORTER_OTLP_* variables
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter" env:"OTEL_TRACES_EXPORTER" default:"none"`
+	ServiceName  string  `yaml:"service_name" env:"OTEL_SERVICE_NAME" default:"gin-template"`
+	SamplerRatio float64 `yaml:"sampler_ratio" env:"OTEL_TRACES_SAMPLER_ARG" default:"1"`
+}
+
+// GiteaConfig holds the Gitea integration settings
+type GiteaConfig struct {
+	OrgName     string `yaml:"org_name" env:"GITEA_ORG_NAME"`
+	CommitEmail string `yaml:"commit_email" env:"GITEA_COMMIT_EMAIL"`
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedValue replaces secret values in Dump
+const RedactedValue = "[REDACTED]"
+
+// Dump returns the configuration as YAML with secrets redacted, for debugging
+func (c *Config) Dump() string {
+	root := map[string]interface{}{}
+	walk(reflect.ValueOf(c).Elem(), nil, func(field reflect.Value, info fieldInfo) {
+		section := root
+		for _, key := range info.path[:len(info.path)-1] {
+			next, ok := section[key].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				section[key] = next
+			}
+			section = next
+		}
+
+		var value interface{} = field.Interface()
+		if duration, ok := value.(time.Duration); ok {
+			value = duration.String()
+		}
+		if info.secret && !field.IsZero() {
+			value = RedactedValue
+		}
+		section[info.path[len(info.path)-1]] = value
+	})
+
+	out, err := yaml.Marshal(root)
+	if err != nil {
+		return err.Error()
+	}
+	return strings.TrimRight(string(out), "\n")
+}
@@ -0,0 +1,296 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	current *Config
+	once    sync.Once
+)
+
+// Get returns the process configuration, loading it on first use. An
+// invalid configuration prints every problem and exits, the same way the
+// packages reading it used to fail at startup.
+func Get() *Config {
+	once.Do(func() {
+		if current != nil {
+			return
+		}
+		cfg, err := Load()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		current = cfg
+	})
+	return current
+}
+
+// Set replaces the process configuration, it must be called before the first Get
+func Set(cfg *Config) {
+	current = cfg
+}
+
+// Load reads the configuration from the environment, the .env file and the
+// optional YAML or TOML file named by CONFIG_FILE, in that order of
+// precedence, then applies defaults and validates the result. The returned
+// error lists every problem at once.
+func Load() (*Config, error) {
+	// Variables already set in the environment win over the .env file
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to load .env file: %w", err)
+	}
+
+	fileValues, err := readFile(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	var problems []string
+	walk(reflect.ValueOf(cfg).Elem(), nil, func(field reflect.Value, info fieldInfo) {
+		raw, source := lookup(info, fileValues)
+		if raw == "" {
+			if info.required {
+				problems = append(problems, fmt.Sprintf("%s is required", info.name()))
+			}
+			return
+		}
+
+		if err := setValue(field, raw, info.unit); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid value %q from %s: %v", info.name(), raw, source, err))
+		}
+	})
+
+	problems = append(problems, cfg.resolve()...)
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	return cfg, nil
+}
+
+// ValidationError lists every configuration problem found while loading
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+//-----------------------------------------------------------------------------
+// Struct Walking
+//-----------------------------------------------------------------------------
+
+// fieldInfo describes a leaf field of Config
+type fieldInfo struct {
+	path     []string // yaml keys from the root, used for the config file and Dump
+	env      string
+	def      string
+	unit     string
+	required bool
+	secret   bool
+}
+
+// name returns the environment variable name, or the file path when the field has none
+func (f fieldInfo) name() string {
+	if f.env != "" {
+		return f.env
+	}
+	return strings.Join(f.path, ".")
+}
+
+// walk calls fn for every leaf field of the struct value
+func walk(value reflect.Value, path []string, fn func(reflect.Value, fieldInfo)) {
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		structField := valueType.Field(i)
+		fieldPath := append(append([]string{}, path...), structField.Tag.Get("yaml"))
+
+		if structField.Type.Kind() == reflect.Struct && structField.Type != reflect.TypeOf(time.Duration(0)) {
+			walk(value.Field(i), fieldPath, fn)
+			continue
+		}
+
+		fn(value.Field(i), fieldInfo{
+			path:     fieldPath,
+			env:      structField.Tag.Get("env"),
+			def:      structField.Tag.Get("default"),
+			unit:     structField.Tag.Get("unit"),
+			required: structField.Tag.Get("required") == "true",
+			secret:   structField.Tag.Get("secret") == "true",
+		})
+	}
+}
+
+// lookup returns the raw value of a field and where it came from
+func lookup(info fieldInfo, fileValues map[string]interface{}) (string, string) {
+	if info.env != "" {
+		if value := strings.TrimSpace(os.Getenv(info.env)); value != "" {
+			return value, "environment"
+		}
+	}
+	if value, ok := fileValue(fileValues, info.path); ok {
+		return value, "config file"
+	}
+	return info.def, "default"
+}
+
+//-----------------------------------------------------------------------------
+// Config File
+//-----------------------------------------------------------------------------
+
+// readFile decodes a YAML or TOML file into nested maps, an empty path means no file
+func readFile(path string) (map[string]interface{}, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension: %s", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+// fileValue finds a value by its yaml key path and formats it like an environment variable
+func fileValue(values map[string]interface{}, path []string) (string, bool) {
+	var current interface{} = values
+	for _, key := range path {
+		section, ok := current.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if current, ok = section[key]; !ok {
+			return "", false
+		}
+	}
+
+	switch value := current.(type) {
+	case nil:
+		return "", false
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ","), true
+	case map[string]interface{}:
+		return "", false
+	default:
+		return fmt.Sprint(value), true
+	}
+}
+
+//-----------------------------------------------------------------------------
+// Value Parsing
+//-----------------------------------------------------------------------------
+
+// durationUnits maps the unit tag to the duration of a bare number
+var durationUnits = map[string]time.Duration{
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+}
+
+// setValue parses raw into the field according to its type
+func setValue(field reflect.Value, raw string, unit string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		duration, err := parseDuration(raw, unit)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		field.SetBool(value)
+	case reflect.Int, reflect.Int64:
+		value, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		field.SetInt(value)
+	case reflect.Uint8, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a positive integer below %d", uint64(1)<<field.Type().Bits())
+		}
+		field.SetUint(value)
+	case reflect.Float64:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		field.SetFloat(value)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
+
+// parseDuration accepts Go durations ("15s") or bare numbers in the field unit
+func parseDuration(raw string, unit string) (time.Duration, error) {
+	if number, err := strconv.ParseFloat(raw, 64); err == nil {
+		multiplier, ok := durationUnits[unit]
+		if !ok {
+			multiplier = time.Second
+		}
+		return time.Duration(number * float64(multiplier)), nil
+	}
+
+	duration, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, errors.New("must be a duration such as 30s or a number")
+	}
+	return duration, nil
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// baseEnv is the smallest environment that loads without problems
+var baseEnv = map[string]string{
+	"MACHINE_ID":     "1",
+	"BASE_URL":       "https://example.com",
+	"JWT_SECRET_KEY": "secret",
+	"DATABASE_TYPE":  "sqlite",
+}
+
+// load loads the config from baseEnv with env on top, and from file as the
+// YAML config file when it is not empty
+func load(t *testing.T, env map[string]string, file string) (*Config, error) {
+	t.Helper()
+	for key, value := range baseEnv {
+		t.Setenv(key, value)
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+
+	path := ""
+	if file != "" {
+		path = filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("CONFIG_FILE", path)
+
+	return Load()
+}
+
+// checkProblem fails unless err is a ValidationError with a problem containing want
+func checkProblem(t *testing.T, err error, want string) {
+	t.Helper()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("got error %v, want a validation error containing %q", err, want)
+	}
+	for _, problem := range validationErr.Problems {
+		if strings.Contains(problem, want) {
+			return
+		}
+	}
+	t.Fatalf("got problems %q, want one containing %q", validationErr.Problems, want)
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		file    string
+		check   func(t *testing.T, cfg *Config)
+		problem string
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Server.Port != "8080" || cfg.Server.ReadTimeout != 15*time.Second || !cfg.CORS.AllowCredentials {
+					t.Errorf("got port %q, read timeout %s and credentials %t", cfg.Server.Port, cfg.Server.ReadTimeout, cfg.CORS.AllowCredentials)
+				}
+			},
+		},
+		{
+			name: "file over default",
+			file: "server:\n  port: 9000\ncors:\n  allowed_origins:\n    - https://a.example.com\n    - https://b.example.com\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Server.Port != "9000" {
+					t.Errorf("got port %q, want 9000", cfg.Server.Port)
+				}
+				if len(cfg.CORS.AllowedOrigins) != 2 || cfg.CORS.AllowedOrigins[1] != "https://b.example.com" {
+					t.Errorf("got allowed origins %q", cfg.CORS.AllowedOrigins)
+				}
+			},
+		},
+		{
+			name: "environment over file",
+			env:  map[string]string{"PORT": "9100"},
+			file: "server:\n  port: 9000\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Server.Port != "9100" {
+					t.Errorf("got port %q, want 9100", cfg.Server.Port)
+				}
+			},
+		},
+		{
+			name: "bare numbers in the field unit",
+			env: map[string]string{
+				"SERVER_READ_TIMEOUT":           "20",
+				"DATABASE_SQLITE_BUSY_TIMEOUT":  "250",
+				"ACCOUNT_DELETION_GRACE_PERIOD": "2",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Server.ReadTimeout != 20*time.Second {
+					t.Errorf("got read timeout %s, want 20s", cfg.Server.ReadTimeout)
+				}
+				if cfg.Database.SQLiteBusyTimeout != 250*time.Millisecond {
+					t.Errorf("got busy timeout %s, want 250ms", cfg.Database.SQLiteBusyTimeout)
+				}
+				if cfg.Account.DeletionGracePeriod != 48*time.Hour {
+					t.Errorf("got grace period %s, want 48h", cfg.Account.DeletionGracePeriod)
+				}
+			},
+		},
+		{
+			name: "go durations",
+			env:  map[string]string{"SERVER_READ_TIMEOUT": "1m30s"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Server.ReadTimeout != 90*time.Second {
+					t.Errorf("got read timeout %s, want 1m30s", cfg.Server.ReadTimeout)
+				}
+			},
+		},
+		{
+			name:    "required",
+			env:     map[string]string{"JWT_SECRET_KEY": ""},
+			problem: "JWT_SECRET_KEY is required",
+		},
+		{
+			name:    "invalid integer",
+			env:     map[string]string{"MACHINE_ID": "abc"},
+			problem: `MACHINE_ID: invalid value "abc" from environment`,
+		},
+		{
+			name:    "invalid duration",
+			file:    "server:\n  read_timeout: soon\n",
+			problem: `SERVER_READ_TIMEOUT: invalid value "soon" from config file`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(t, tt.env, tt.file)
+			if tt.problem != "" {
+				checkProblem(t, err, tt.problem)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// databaseSchemes maps the DATABASE_URL schemes to the database types
+var databaseSchemes = map[string]string{
+	"postgres":   "postgres",
+	"postgresql": "postgres",
+	"mysql":      "mysql",
+	"mariadb":    "mariadb",
+	"sqlite":     "sqlite",
+	"sqlite3":    "sqlite",
+}
+
+// resolve derives the settings implied by other settings, before validation
+func (c *Config) resolve() []string {
+	if c.Database.URL != "" {
+		u, err := url.Parse(c.Database.URL)
+		if err != nil {
+			return []string{"DATABASE_URL must be a valid URL"}
+		}
+		dbType, ok := databaseSchemes[strings.ToLower(u.Scheme)]
+		if !ok {
+			return []string{fmt.Sprintf("DATABASE_URL scheme must be one of postgres, postgresql, mysql, mariadb, sqlite, got %q", u.Scheme)}
+		}
+		c.Database.Type = dbType
+	}
+	if c.Storage.Backend == "" {
+		c.Storage.Backend = "local"
+		if c.S3.Enabled {
+			c.Storage.Backend = "s3"
+		}
+	}
+	return nil
+}
+
+// validate checks rules that depend on more than one field
+func (c *Config) validate() []string {
+	var problems []string
+
+	problems = append(problems, oneOf("GIN_MODE", c.App.GinMode, "debug", "release", "test")...)
+	if c.App.MachineID < 0 || c.App.MachineID > 1023 {
+		problems = append(problems, "MACHINE_ID must be between 0 and 1023")
+	}
+	if c.App.BaseURL != "" {
+		if u, err := url.Parse(c.App.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, "BASE_URL must be an absolute http or https URL")
+		}
+	}
+
+	problems = append(problems, oneOf("DATABASE_TYPE", c.Database.Type, "postgres", "mysql", "mariadb", "sqlite")...)
+	if c.Database.Type != "sqlite" && c.Database.URL == "" {
+		problems = append(problems, requiredFor("DATABASE_TYPE="+c.Database.Type, map[string]string{
+			"DATABASE_HOST":   c.Database.Host,
+			"DATABASE_PORT":   c.Database.Port,
+			"DATABASE_USER":   c.Database.User,
+			"DATABASE_DBNAME": c.Database.DBName,
+		})...)
+	}
+	if c.Database.TLS != "" {
+		problems = append(problems, oneOf("DATABASE_TLS", c.Database.TLS, "true", "false", "skip-verify", "preferred")...)
+	}
+	if (c.Database.TLSCert == "") != (c.Database.TLSKey == "") {
+		problems = append(problems, "DATABASE_TLS_CERT and DATABASE_TLS_KEY must be set together")
+	}
+	problems = append(problems, oneOf("DATABASE_SQLITE_JOURNAL_MODE", strings.ToUpper(c.Database.SQLiteJournalMode), "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF")...)
+	if c.Database.SQLiteBusyTimeout < 0 {
+		problems = append(problems, "DATABASE_SQLITE_BUSY_TIMEOUT must not be negative")
+	}
+	if c.Database.ConnectTimeout < 0 {
+		problems = append(problems, "DATABASE_CONNECT_TIMEOUT must not be negative")
+	}
+	if len(c.Database.Replicas) > 0 {
+		problems = append(problems, c.validateReplicas()...)
+	}
+	if c.Database.MaxOpenConns < 1 {
+		problems = append(problems, "DATABASE_MAX_OPEN_CONNS must be at least 1")
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		problems = append(problems, "DATABASE_MAX_IDLE_CONNS must be between 0 and DATABASE_MAX_OPEN_CONNS")
+	}
+
+	if c.Cache.Enabled {
+		problems = append(problems, requiredFor("CACHE_ENABLED=true", map[string]string{
+			"CACHE_HOST": c.Cache.Host,
+			"CACHE_PORT": c.Cache.Port,
+		})...)
+	}
+	if c.S3.Enabled {
+		problems = append(problems, requiredFor("S3_ENABLED=true", map[string]string{
+			"S3_ENDPOINT":      c.S3.Endpoint,
+			"S3_STATIC_BUCKET": c.S3.StaticBucket,
+		})...)
+	}
+	problems = append(problems, oneOf("STORAGE_BACKEND", c.Storage.Backend, "s3", "local", "memory")...)
+	if c.Storage.Backend == "s3" && !c.S3.Enabled {
+		problems = append(problems, "STORAGE_BACKEND=s3 requires S3_ENABLED=true")
+	}
+
+	if c.Argon2.Memory < 8*uint32(c.Argon2.Parallelism) {
+		problems = append(problems, "ARGON2_MEMORY must be at least 8 times ARGON2_PARALLELISM")
+	}
+	if c.Argon2.Iterations < 1 {
+		problems = append(problems, "ARGON2_ITERATIONS must be at least 1")
+	}
+	if c.Argon2.Parallelism < 1 {
+		problems = append(problems, "ARGON2_PARALLELISM must be at least 1")
+	}
+
+	problems = append(problems, oneOf("COOKIE_SAME_SITE", strings.ToLower(c.Cookie.SameSite), "lax", "strict", "none")...)
+	if strings.EqualFold(c.Cookie.SameSite, "none") && !strings.HasPrefix(c.App.BaseURL, "https://") {
+		problems = append(problems, "COOKIE_SAME_SITE=none requires an https BASE_URL")
+	}
+	if c.Cookie.RefreshTokenExpires < 1 {
+		problems = append(problems, "COOKIE_REFRESH_TOKEN_EXPIRES must be at least 1 day")
+	}
+	if c.Cookie.AccessTokenExpires < 1 {
+		problems = append(problems, "COOKIE_ACCESS_TOKEN_EXPIRES must be at least 1 minute")
+	}
+
+	if c.Account.DeletionGracePeriod < 0 {
+		problems = append(problems, "ACCOUNT_DELETION_GRACE_PERIOD must not be negative")
+	}
+	if c.Account.PurgeInterval <= 0 {
+		problems = append(problems, "ACCOUNT_PURGE_INTERVAL must be positive")
+	}
+	if c.Export.Interval < 0 {
+		problems = append(problems, "USER_EXPORT_INTERVAL must not be negative")
+	}
+	if c.Export.LinkTTL <= 0 || c.Export.LinkTTL > 7*24*time.Hour {
+		// S3 rejects presigned URLs valid for more than 7 days
+		problems = append(problems, "USER_EXPORT_LINK_TTL must be between 1 second and 7 days")
+	}
+	if c.Export.WorkerInterval <= 0 {
+		problems = append(problems, "USER_EXPORT_WORKER_INTERVAL must be positive")
+	}
+	if c.Avatar.MaxSize < 1 {
+		problems = append(problems, "AVATAR_MAX_SIZE must be at least 1 byte")
+	}
+	if c.Avatar.MaxDimension < 1 {
+		problems = append(problems, "AVATAR_MAX_DIMENSION must be at least 1 pixel")
+	}
+	if c.Upload.MaxSize < 1 {
+		problems = append(problems, "UPLOAD_MAX_SIZE must be at least 1 byte")
+	}
+	if c.Upload.URLTTL <= 0 || c.Upload.URLTTL > 7*24*time.Hour {
+		problems = append(problems, "UPLOAD_URL_TTL must be between 1 second and 7 days")
+	}
+	if c.Upload.SweepInterval <= 0 {
+		problems = append(problems, "UPLOAD_SWEEP_INTERVAL must be positive")
+	}
+
+	if c.Log.Level != "" {
+		problems = append(problems, oneOf("LOG_LEVEL", strings.ToLower(c.Log.Level), "debug", "info", "warn", "error", "dpanic", "panic", "fatal")...)
+	}
+	if c.Log.Output != "" {
+		problems = append(problems, oneOf("LOG_OUTPUT", c.Log.Output, "stdout", "console", "file")...)
+	}
+
+	if c.CORS.AllowCredentials {
+		for _, origin := range c.CORS.AllowedOrigins {
+			if strings.TrimSpace(origin) == "*" {
+				// Any site could then read the responses with the cookies of the user, CSRF token included
+				problems = append(problems, `CORS_ALLOWED_ORIGINS can't contain "*" when CORS_ALLOW_CREDENTIALS is true`)
+				break
+			}
+		}
+	}
+
+	problems = append(problems, oneOf("OTEL_TRACES_EXPORTER", strings.ToLower(c.Tracing.Exporter), "none", "otlp", "stdout")...)
+	if c.Tracing.SamplerRatio < 0 || c.Tracing.SamplerRatio > 1 {
+		problems = append(problems, "OTEL_TRACES_SAMPLER_ARG must be between 0 and 1")
+	}
+
+	if c.Health.CheckTimeout <= 0 {
+		problems = append(problems, "HEALTH_CHECK_TIMEOUT must be positive")
+	}
+
+	return problems
+}
+
+// validateReplicas checks that every replica URL uses the driver of the primary
+func (c *Config) validateReplicas() []string {
+	if c.Database.Type == "sqlite" {
+		return []string{"DATABASE_REPLICAS is not supported with sqlite"}
+	}
+
+	// MariaDB and MySQL share a driver
+	driver := func(dbType string) string {
+		if dbType == "mariadb" {
+			return "mysql"
+		}
+		return dbType
+	}
+
+	var problems []string
+	for i, replica := range c.Database.Replicas {
+		u, err := url.Parse(replica)
+		if err != nil || driver(databaseSchemes[strings.ToLower(u.Scheme)]) != driver(c.Database.Type) {
+			problems = append(problems, fmt.Sprintf("DATABASE_REPLICAS entry %d must be a %s URL", i+1, c.Database.Type))
+		}
+	}
+	if c.Database.ReplicaCheckInterval <= 0 {
+		problems = append(problems, "DATABASE_REPLICA_CHECK_INTERVAL must be positive")
+	}
+	return problems
+}
+
+// oneOf reports a problem when value is not one of the allowed values
+func oneOf(name string, value string, allowed ...string) []string {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("%s must be one of %s, got %q", name, strings.Join(allowed, ", "), value)}
+}
+
+// requiredFor reports every empty field that is required because of reason
+func requiredFor(reason string, fields map[string]string) []string {
+	var problems []string
+	for _, name := range sortedKeys(fields) {
+		if fields[name] == "" {
+			problems = append(problems, fmt.Sprintf("%s is required when %s", name, reason))
+		}
+	}
+	return problems
+}
+
+// sortedKeys returns the map keys in alphabetical order
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
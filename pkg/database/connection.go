@@ -1,20 +1,18 @@
 package db
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"time"
 
+	"github.com/yorukot/go-template/pkg/config"
 	"github.com/yorukot/go-template/pkg/logger"
-	"gorm.io/driver/mysql"
-	"gorm.io/driver/postgres"
-	"gorm.io/driver/sqlite"
+	"github.com/yorukot/go-template/pkg/metrics"
+	"github.com/yorukot/go-template/pkg/tracing"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 )
 
-// DB is the global GORM database instance
-var DB *gorm.DB
-
 //-----------------------------------------------------------------------------
 // Database Types and Configuration
 //-----------------------------------------------------------------------------
@@ -27,176 +25,165 @@ const (
 	SQLite     = "sqlite"   // SQLite database
 )
 
-// init initializes the database connection when the package is imported
-// Database configuration is read from environment variables:
+// Connection retry backoff, the delay doubles after every failed attempt
+const (
+	initialRetryDelay = 500 * time.Millisecond
+	maxRetryDelay     = 10 * time.Second
+)
+
+// Open connects to the database described by cfg, configures the connection
+// pool and registers query metrics and tracing. The caller owns the returned
+// connection and must Close it.
+// Database configuration is read from the database section of the config:
 //   - DATABASE_TYPE: Type of database to connect to (default: postgres)
+//   - DATABASE_URL: Connection URL replacing the settings below, its scheme sets the type
 //   - DATABASE_HOST: Database server hostname or IP
 //   - DATABASE_PORT: Database server port
 //   - DATABASE_USER: Database username
 //   - DATABASE_PASSWORD: Database password
 //   - DATABASE_DBNAME: Database name
 //   - DATABASE_SSLMODE: SSL mode for PostgreSQL
+//   - DATABASE_CHARSET: Connection charset for MySQL
+//   - DATABASE_TLS: TLS mode for MySQL
+//   - DATABASE_TLS_CA, DATABASE_TLS_CERT, DATABASE_TLS_KEY: CA and client certificate files
 //   - DATABASE_PATH: File path for SQLite database
+//   - DATABASE_SQLITE_JOURNAL_MODE, DATABASE_SQLITE_BUSY_TIMEOUT: SQLite pragmas
 //   - DATABASE_MAX_IDLE_CONNS: Maximum number of idle connections
 //   - DATABASE_MAX_OPEN_CONNS: Maximum number of open connections
 //   - DATABASE_CONN_MAX_LIFETIME: Maximum lifetime of connections in minutes
-func init() {
-	// Get database type from environment
-	dbType := os.Getenv("DATABASE_TYPE")
-	if dbType == "" {
-		dbType = PostgreSQL // Default to PostgreSQL if not specified
-		logger.Log.Sugar().Info("DATABASE_TYPE not set, defaulting to PostgreSQL")
-	}
+//   - DATABASE_CONNECT_TIMEOUT: How long to retry the first connection
+//   - DATABASE_REPLICAS: Read replica URLs, see useReplicas
+func Open(ctx context.Context, cfg config.DatabaseConfig) (*gorm.DB, error) {
+	dbType := cfg.Type
 
-	var err error
-
-	// Initialize the appropriate database based on type
-	switch dbType {
-	case PostgreSQL:
-		logger.Log.Sugar().Info("Initializing PostgreSQL connection")
-		DB, err = initPostgreSQL()
-	case MySQL, MariaDB:
-		logger.Log.Sugar().Infof("Initializing %s connection", dbType)
-		DB, err = initMySQL()
-	case SQLite:
-		logger.Log.Sugar().Info("Initializing SQLite connection")
-		DB, err = initSQLite()
-	default:
-		logger.Log.Sugar().Fatalf("Unsupported database type: %s", dbType)
+	dsn, err := buildDSN(cfg)
+	if err != nil {
+		return nil, err
 	}
 
+	logger.Log.Sugar().Infof("Initializing %s connection", dbType)
+	conn, err := connect(ctx, cfg, dsn)
 	if err != nil {
-		logger.Log.Sugar().Fatalf("Failed to connect to %s database: %v", dbType, err)
+		return nil, fmt.Errorf("failed to connect to %s database: %w", dbType, err)
 	}
 
 	// Configure connection pool
-	configureConnectionPool(dbType)
-
-	logger.Log.Sugar().Infof("Successfully connected to %s database", dbType)
-}
-
-// configureConnectionPool sets up the database connection pool parameters
-func configureConnectionPool(dbType string) {
-	sqlDB, err := DB.DB()
-	if err != nil {
-		logger.Log.Sugar().Fatalf("Failed to get SQL DB instance: %v", err)
+	if err := configureConnectionPool(conn, cfg); err != nil {
+		return nil, err
 	}
 
-	// Get connection pool settings from environment or use defaults
-	maxIdleConns := getEnvIntWithDefault("DATABASE_MAX_IDLE_CONNS", 10)
-	maxOpenConns := getEnvIntWithDefault("DATABASE_MAX_OPEN_CONNS", 100)
-	connMaxLifetime := time.Duration(getEnvIntWithDefault("DATABASE_CONN_MAX_LIFETIME", 30)) * time.Minute
-
-	sqlDB.SetMaxIdleConns(maxIdleConns)
-	sqlDB.SetMaxOpenConns(maxOpenConns)
-	sqlDB.SetConnMaxLifetime(connMaxLifetime)
-
-	// Check connection
-	if err = sqlDB.Ping(); err != nil {
-		logger.Log.Sugar().Fatalf("Failed to ping %s database: %v", dbType, err)
+	if len(cfg.Replicas) > 0 {
+		if err := useReplicas(ctx, conn, cfg); err != nil {
+			_ = Close(conn)
+			return nil, err
+		}
 	}
-}
 
-//-----------------------------------------------------------------------------
-// Database Initialization Functions
-//-----------------------------------------------------------------------------
-
-// initPostgreSQL initializes a PostgreSQL connection
-// Required env vars: DATABASE_HOST, DATABASE_USER, DATABASE_PASSWORD,
-// DATABASE_DBNAME, DATABASE_PORT, DATABASE_SSLMODE
-func initPostgreSQL() (*gorm.DB, error) {
-	host := os.Getenv("DATABASE_HOST")
-	user := os.Getenv("DATABASE_USER")
-	password := os.Getenv("DATABASE_PASSWORD")
-	dbname := os.Getenv("DATABASE_DBNAME")
-	port := os.Getenv("DATABASE_PORT")
-	sslmode := os.Getenv("DATABASE_SSLMODE")
-
-	// Validate required parameters
-	if host == "" || user == "" || dbname == "" || port == "" {
-		return nil, fmt.Errorf("missing required PostgreSQL connection parameters")
+	// Export query durations and connection pool stats
+	if err := metrics.RegisterDatabase(conn, dbType); err != nil {
+		logger.Log.Sugar().Warnf("Failed to register database metrics: %v", err)
 	}
 
-	// Build connection string
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		host, user, password, dbname, port, sslmode,
-	)
+	// Create a span for every query
+	if err := tracing.RegisterDatabase(conn, dbType); err != nil {
+		logger.Log.Sugar().Warnf("Failed to register database tracing: %v", err)
+	}
 
-	return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	logger.Log.Sugar().Infof("Successfully connected to %s database", dbType)
+	return conn, nil
 }
 
-// initMySQL initializes a MySQL or MariaDB connection
-// Required env vars: DATABASE_HOST, DATABASE_USER, DATABASE_PASSWORD,
-// DATABASE_DBNAME, DATABASE_PORT
-func initMySQL() (*gorm.DB, error) {
-	host := os.Getenv("DATABASE_HOST")
-	user := os.Getenv("DATABASE_USER")
-	password := os.Getenv("DATABASE_PASSWORD")
-	dbname := os.Getenv("DATABASE_DBNAME")
-	port := os.Getenv("DATABASE_PORT")
-
-	// Validate required parameters
-	if host == "" || user == "" || dbname == "" || port == "" {
-		return nil, fmt.Errorf("missing required MySQL connection parameters")
+// connect opens and pings the connection, retrying with exponential backoff
+// until DATABASE_CONNECT_TIMEOUT since the database container often starts
+// after the app. A timeout of zero tries once.
+func connect(ctx context.Context, cfg config.DatabaseConfig, dsn string) (*gorm.DB, error) {
+	if cfg.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.ConnectTimeout)
+		defer cancel()
 	}
 
-	// Build connection string
-	dsn := fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s?parseTime=True&loc=Local",
-		user, password, host, port, dbname,
-	)
-
-	return gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	// Failed attempts are logged once below instead of by GORM
+	gormConfig := newGormConfig()
+	gormConfig.Logger = gormlogger.Default.LogMode(gormlogger.Silent)
+
+	delay := initialRetryDelay
+	for attempt := 1; ; attempt++ {
+		conn, err := gorm.Open(newDialector(cfg.Type, dsn), gormConfig)
+		if err == nil {
+			if err = Ping(ctx, conn); err == nil {
+				conn.Logger = gormlogger.Default
+				return conn, nil
+			}
+		}
+		if conn != nil {
+			// The pool of a failed attempt is never used again
+			if sqlDB, dbErr := conn.DB(); dbErr == nil {
+				_ = sqlDB.Close()
+			}
+		}
+
+		if cfg.ConnectTimeout == 0 {
+			return nil, err
+		}
+		logger.Log.Sugar().Warnf("Database is not reachable (attempt %d), retrying in %s: %v", attempt, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gave up after %d attempts in %s: %w", attempt, cfg.ConnectTimeout, err)
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
 }
 
-// initSQLite initializes a SQLite connection
-// Required env vars: DATABASE_PATH (or uses default "database.db")
-func initSQLite() (*gorm.DB, error) {
-	dbPath := os.Getenv("DATABASE_PATH")
-	if dbPath == "" {
-		dbPath = "database.db" // Default SQLite database file
-		logger.Log.Sugar().Info("DATABASE_PATH not set, using default: database.db")
+// configureConnectionPool sets up the database connection pool parameters
+func configureConnectionPool(conn *gorm.DB, cfg config.DatabaseConfig) error {
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get SQL DB instance: %w", err)
 	}
 
-	return gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	return nil
+}
+
+// newGormConfig returns the GORM settings shared by every driver, driver errors
+// such as unique violations are translated to gorm.ErrDuplicatedKey
+func newGormConfig() *gorm.Config {
+	// connect pings with a context instead, and replicas must open while down
+	return &gorm.Config{TranslateError: true, DisableAutomaticPing: true}
 }
 
 //-----------------------------------------------------------------------------
 // Utility Functions
 //-----------------------------------------------------------------------------
 
-// getEnvIntWithDefault gets an integer from environment variable or returns the default value
-func getEnvIntWithDefault(key string, defaultVal int) int {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultVal
-	}
-
-	var intVal int
-	_, err := fmt.Sscanf(value, "%d", &intVal)
+// Ping checks that the database is reachable through the connection pool
+func Ping(ctx context.Context, conn *gorm.DB) error {
+	sqlDB, err := conn.DB()
 	if err != nil {
-		logger.Log.Sugar().Warnf("Invalid value for %s: %s, using default: %d", key, value, defaultVal)
-		return defaultVal
+		return err
 	}
-
-	return intVal
+	return sqlDB.PingContext(ctx)
 }
 
-// GetDB returns the GORM DB instance
-func GetDB() *gorm.DB {
-	return DB
-}
+// Close closes the database connection pool and the replica pools
+func Close(conn *gorm.DB) error {
+	if replicas, ok := conn.Config.Plugins[replicaPluginName].(*replicaSet); ok {
+		replicas.close()
+	}
 
-// CloseDatabase closes the database connection
-func CloseDatabase() {
-	sqlDB, err := DB.DB()
+	sqlDB, err := conn.DB()
 	if err != nil {
-		logger.Log.Sugar().Fatal("Failed to get SQL DB instance:", err)
+		return fmt.Errorf("failed to get SQL DB instance: %w", err)
 	}
 
 	if err := sqlDB.Close(); err != nil {
-		logger.Log.Sugar().Fatal("Failed to close database connection:", err)
+		return fmt.Errorf("failed to close database connection: %w", err)
 	}
 	logger.Log.Info("Successfully disconnected from database")
+	return nil
 }
@@ -0,0 +1,217 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/yorukot/go-template/pkg/config"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+//-----------------------------------------------------------------------------
+// DSN Building
+//-----------------------------------------------------------------------------
+
+// mysqlTLSConfigPrefix starts the names the custom MySQL TLS configs are
+// registered under, one per host since the config verifies the host name
+const mysqlTLSConfigPrefix = "custom-"
+
+// errInvalidURL is returned for a DATABASE_URL that can't be parsed, the URL
+// is left out of the message because it holds the password
+var errInvalidURL = errors.New("invalid DATABASE_URL")
+
+// buildDSN returns the driver DSN, from DATABASE_URL when it is set and from
+// the separate connection settings otherwise
+func buildDSN(cfg config.DatabaseConfig) (string, error) {
+	switch cfg.Type {
+	case PostgreSQL:
+		return postgresDSN(cfg)
+	case MySQL, MariaDB:
+		return mysqlDSN(cfg)
+	case SQLite:
+		return sqliteDSN(cfg)
+	default:
+		return "", fmt.Errorf("unsupported database type: %s", cfg.Type)
+	}
+}
+
+// newDialector returns a GORM dialector for the DSN, a new one is used for
+// every connection attempt
+func newDialector(dbType string, dsn string) gorm.Dialector {
+	switch dbType {
+	case MySQL, MariaDB:
+		return mysql.Open(dsn)
+	case SQLite:
+		return sqlite.Open(dsn)
+	default:
+		return postgres.Open(dsn)
+	}
+}
+
+// postgresDSN passes DATABASE_URL to the driver as is, or builds a keyword
+// DSN from DATABASE_HOST, DATABASE_PORT, DATABASE_USER, DATABASE_PASSWORD,
+// DATABASE_DBNAME and DATABASE_SSLMODE. DATABASE_TLS_CA, DATABASE_TLS_CERT
+// and DATABASE_TLS_KEY become sslrootcert, sslcert and sslkey.
+func postgresDSN(cfg config.DatabaseConfig) (string, error) {
+	tlsParams := [][2]string{
+		{"sslrootcert", cfg.TLSCA},
+		{"sslcert", cfg.TLSCert},
+		{"sslkey", cfg.TLSKey},
+	}
+
+	if cfg.URL != "" {
+		u, err := url.Parse(cfg.URL)
+		if err != nil {
+			return "", errInvalidURL
+		}
+		query := u.Query()
+		for _, param := range tlsParams {
+			setDefault(query, param[0], param[1])
+		}
+		u.RawQuery = query.Encode()
+		return u.String(), nil
+	}
+
+	params := append([][2]string{
+		{"host", cfg.Host},
+		{"user", cfg.User},
+		{"password", cfg.Password},
+		{"dbname", cfg.DBName},
+		{"port", cfg.Port},
+		{"sslmode", cfg.SSLMode},
+	}, tlsParams...)
+
+	// Quote every value so passwords with spaces or quotes survive
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	pairs := make([]string, 0, len(params))
+	for _, param := range params {
+		if param[1] != "" {
+			pairs = append(pairs, fmt.Sprintf("%s='%s'", param[0], quote.Replace(param[1])))
+		}
+	}
+	return strings.Join(pairs, " "), nil
+}
+
+// mysqlDSN builds a go-sql-driver DSN from DATABASE_URL or the separate
+// settings. Query parameters of the URL win over DATABASE_CHARSET and
+// DATABASE_TLS.
+func mysqlDSN(cfg config.DatabaseConfig) (string, error) {
+	user, password, host, port, dbName := cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName
+	query := url.Values{}
+
+	if cfg.URL != "" {
+		u, err := url.Parse(cfg.URL)
+		if err != nil {
+			return "", errInvalidURL
+		}
+		user = u.User.Username()
+		password, _ = u.User.Password()
+		host, port = u.Hostname(), u.Port()
+		dbName = strings.TrimPrefix(u.Path, "/")
+		query = u.Query()
+	}
+	if port == "" {
+		port = "3306"
+	}
+
+	setDefault(query, "parseTime", "True")
+	setDefault(query, "loc", "Local")
+	setDefault(query, "charset", cfg.Charset)
+
+	tlsName, err := mysqlTLS(cfg, host)
+	if err != nil {
+		return "", err
+	}
+	setDefault(query, "tls", tlsName)
+
+	return fmt.Sprintf("%s:%s@tcp(%s)/%s?%s", user, password, net.JoinHostPort(host, port), dbName, query.Encode()), nil
+}
+
+// mysqlTLS returns the tls parameter of the MySQL DSN. DATABASE_TLS is one of
+// the driver modes, a CA or client certificate registers a custom config
+// that verifies the server against the CA and presents the certificate.
+func mysqlTLS(cfg config.DatabaseConfig, host string) (string, error) {
+	if cfg.TLS == "false" || (cfg.TLSCA == "" && cfg.TLSCert == "") {
+		return cfg.TLS, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         host,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.TLS == "skip-verify", // Explicitly requested
+	}
+
+	if cfg.TLSCA != "" {
+		pem, err := os.ReadFile(cfg.TLSCA)
+		if err != nil {
+			return "", fmt.Errorf("failed to read DATABASE_TLS_CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return "", errors.New("DATABASE_TLS_CA holds no PEM certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to load DATABASE_TLS_CERT and DATABASE_TLS_KEY: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	name := mysqlTLSConfigPrefix + host
+	if err := mysqldriver.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return "", fmt.Errorf("failed to register MySQL TLS config: %w", err)
+	}
+	return name, nil
+}
+
+// sqliteDSN returns the database file with the connection pragmas. Foreign
+// keys are off by default in SQLite, which would silently ignore the ON
+// DELETE CASCADE of the sessions. WAL lets readers run during a write and
+// the busy timeout makes writers wait for the lock instead of failing.
+func sqliteDSN(cfg config.DatabaseConfig) (string, error) {
+	path, rawQuery := cfg.Path, ""
+
+	if cfg.URL != "" {
+		// sqlite:///abs/path.db, sqlite://relative.db or sqlite:relative.db
+		_, rest, found := strings.Cut(cfg.URL, ":")
+		if !found {
+			return "", errInvalidURL
+		}
+		path, rawQuery, _ = strings.Cut(strings.TrimPrefix(rest, "//"), "?")
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", errInvalidURL
+	}
+	setDefault(query, "_foreign_keys", "on")
+	setDefault(query, "_journal_mode", strings.ToUpper(cfg.SQLiteJournalMode))
+	setDefault(query, "_busy_timeout", strconv.FormatInt(cfg.SQLiteBusyTimeout.Milliseconds(), 10))
+	// Take the write lock when a transaction starts, a read lock upgraded
+	// later fails at once with SQLITE_BUSY whatever the busy timeout
+	setDefault(query, "_txlock", "immediate")
+
+	return path + "?" + query.Encode(), nil
+}
+
+// setDefault sets the query parameter unless it is already set or value is empty
+func setDefault(query url.Values, key string, value string) {
+	if value != "" && !query.Has(key) {
+		query.Set(key, value)
+	}
+}
@@ -0,0 +1,483 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yorukot/go-template/pkg/logger"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// Tables used to track applied migrations and to block concurrent runs
+const (
+	MigrationsTable     = "schema_migrations"
+	MigrationsLockTable = "schema_migrations_lock"
+)
+
+// ErrMigrationLocked is returned when another process holds the migration lock for longer than the lock timeout
+var ErrMigrationLocked = errors.New("migrations are locked by another process")
+
+// nonNameChars matches runs of characters not allowed in migration names
+var nonNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// dollarQuote matches the opening tag of a PostgreSQL dollar-quoted string such as $$ or $body$
+var dollarQuote = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// migrationFile matches "<version>_<name>.up.sql" and "<version>_<name>.down.sql"
+var migrationFile = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change with its rollback
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time // nil when pending
+	Missing   bool       // applied but its file no longer exists
+}
+
+// Migrator applies the SQL migrations of one database type. Every migration
+// runs in a transaction together with its schema_migrations row, MySQL
+// commits DDL statements implicitly so a failed MySQL migration may need
+// manual cleanup.
+type Migrator struct {
+	db          *gorm.DB
+	dbType      string
+	migrations  []Migration
+	lockTimeout time.Duration
+	owner       string
+}
+
+// NewMigrator loads the migrations for dbType from fsys, which must contain
+// one directory per database type (postgres, mysql, sqlite), MariaDB uses
+// the mysql directory
+func NewMigrator(conn *gorm.DB, dbType string, fsys fs.FS, lockTimeout time.Duration) (*Migrator, error) {
+	migrations, err := loadMigrations(fsys, MigrationDir(dbType))
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	return &Migrator{
+		db:          conn,
+		dbType:      dbType,
+		migrations:  migrations,
+		lockTimeout: lockTimeout,
+		owner:       fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+	}, nil
+}
+
+// MigrationDir returns the migration directory used by a database type
+func MigrationDir(dbType string) string {
+	if dbType == MariaDB {
+		return MySQL
+	}
+	return dbType
+}
+
+// Up applies every pending migration in version order
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func() error {
+		versions, err := m.appliedVersions(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, migration); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the last n applied migrations in reverse version order
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var rolledBack []Migration
+	err := m.withLock(ctx, func() error {
+		versions, err := m.appliedVersions(ctx)
+		if err != nil {
+			return err
+		}
+
+		applied := make([]int64, 0, len(versions))
+		for version := range versions {
+			applied = append(applied, version)
+		}
+		sort.Slice(applied, func(i, j int) bool { return applied[i] > applied[j] })
+
+		for i := 0; i < n && i < len(applied); i++ {
+			migration, ok := m.find(applied[i])
+			if !ok {
+				return fmt.Errorf("migration %d is applied but its file does not exist", applied[i])
+			}
+			if err := m.rollback(ctx, migration); err != nil {
+				return err
+			}
+			rolledBack = append(rolledBack, migration)
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Status lists every known and applied migration in version order
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.ensureTables(ctx); err != nil {
+		return nil, err
+	}
+	versions, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if row, ok := versions[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	for version, row := range versions {
+		if _, ok := m.find(version); !ok {
+			appliedAt := row.AppliedAt
+			statuses = append(statuses, MigrationStatus{Version: version, Name: row.Name, AppliedAt: &appliedAt, Missing: true})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+// Pending returns the number of migrations that have not been applied
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Unlock releases a lock left behind by a process that died while migrating
+func (m *Migrator) Unlock(ctx context.Context) error {
+	if err := m.ensureTables(ctx); err != nil {
+		return err
+	}
+	return m.db.WithContext(ctx).Exec("DELETE FROM " + MigrationsLockTable).Error
+}
+
+//-----------------------------------------------------------------------------
+// Applying Migrations
+//-----------------------------------------------------------------------------
+
+// schemaMigration is a row of the schema_migrations table
+type schemaMigration struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+// apply runs the up script and records the version in one transaction
+func (m *Migrator) apply(ctx context.Context, migration Migration) error {
+	logger.Log.Sugar().Infof("Applying migration %d_%s", migration.Version, migration.Name)
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := execScript(tx, migration.Up); err != nil {
+			return err
+		}
+		return tx.Exec("INSERT INTO "+MigrationsTable+" (version, name, applied_at) VALUES (?, ?, ?)",
+			migration.Version, migration.Name, time.Now().UTC()).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// rollback runs the down script and removes the version in one transaction
+func (m *Migrator) rollback(ctx context.Context, migration Migration) error {
+	logger.Log.Sugar().Infof("Rolling back migration %d_%s", migration.Version, migration.Name)
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := execScript(tx, migration.Down); err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM "+MigrationsTable+" WHERE version = ?", migration.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// execScript runs every statement of a migration script
+func execScript(tx *gorm.DB, script string) error {
+	for _, statement := range splitStatements(script) {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appliedVersions returns the applied migrations by version
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int64]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := m.db.WithContext(ctx).Table(MigrationsTable).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", MigrationsTable, err)
+	}
+
+	versions := make(map[int64]schemaMigration, len(rows))
+	for _, row := range rows {
+		versions[row.Version] = row
+	}
+	return versions, nil
+}
+
+// find returns the migration file with the version
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+//-----------------------------------------------------------------------------
+// Version Table and Lock
+//-----------------------------------------------------------------------------
+
+// ensureTables creates the version and lock tables
+func (m *Migrator) ensureTables(ctx context.Context) error {
+	timestamp := "TIMESTAMP"
+	switch m.dbType {
+	case PostgreSQL:
+		timestamp = "TIMESTAMPTZ"
+	case MySQL, MariaDB:
+		timestamp = "DATETIME(6)"
+	}
+
+	statements := []string{
+		"CREATE TABLE IF NOT EXISTS " + MigrationsTable + " (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at " + timestamp + " NOT NULL)",
+		"CREATE TABLE IF NOT EXISTS " + MigrationsLockTable + " (id INTEGER NOT NULL PRIMARY KEY, locked_by VARCHAR(255) NOT NULL, locked_at " + timestamp + " NOT NULL)",
+	}
+	for _, statement := range statements {
+		if err := m.db.WithContext(ctx).Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to create migration tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// withLock runs fn while holding the row in the lock table. Inserting the row
+// fails while another process holds it, so concurrent runs wait for up to the
+// lock timeout instead of applying the same migration twice.
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	if err := m.ensureTables(ctx); err != nil {
+		return err
+	}
+
+	// Conflicts are expected while waiting, keep GORM from logging each attempt
+	quiet := m.db.Session(&gorm.Session{Logger: m.db.Logger.LogMode(gormlogger.Silent)})
+
+	deadline := time.Now().Add(m.lockTimeout)
+	for {
+		err := quiet.WithContext(ctx).Exec("INSERT INTO "+MigrationsLockTable+" (id, locked_by, locked_at) VALUES (1, ?, ?)",
+			m.owner, time.Now().UTC()).Error
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w, run \"migrate unlock\" if no migration is running", ErrMigrationLocked)
+		}
+
+		logger.Log.Info("Waiting for the migration lock held by another process")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	defer func() {
+		// Use a fresh context so the lock is released even when ctx was cancelled
+		err := m.db.Exec("DELETE FROM "+MigrationsLockTable+" WHERE id = 1 AND locked_by = ?", m.owner).Error
+		if err != nil {
+			logger.Log.Sugar().Errorf("Failed to release migration lock: %v", err)
+		}
+	}()
+
+	return fn()
+}
+
+//-----------------------------------------------------------------------------
+// Migration Files
+//-----------------------------------------------------------------------------
+
+// loadMigrations reads and pairs the up and down files of a directory
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations for %s: %w", dir, err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// CreateMigration writes empty up and down files for every database type
+// into dir and returns their paths. The version is the UTC time so migrations
+// written on different branches rarely collide.
+func CreateMigration(dir string, name string, now time.Time) ([]string, error) {
+	name = strings.Trim(nonNameChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, errors.New("migration name must contain letters or digits")
+	}
+	version := now.UTC().Format("20060102150405")
+
+	var paths []string
+	for _, dbType := range []string{PostgreSQL, MySQL, SQLite} {
+		if err := os.MkdirAll(path.Join(dir, dbType), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create migration directory: %w", err)
+		}
+		for _, direction := range []string{"up", "down"} {
+			file := path.Join(dir, dbType, fmt.Sprintf("%s_%s.%s.sql", version, name, direction))
+			content := fmt.Sprintf("-- %s migration %s_%s (%s)\n", strings.ToUpper(direction[:1])+direction[1:], version, name, dbType)
+			if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+				return nil, fmt.Errorf("failed to write migration: %w", err)
+			}
+			paths = append(paths, file)
+		}
+	}
+	return paths, nil
+}
+
+// splitStatements splits a script on semicolons outside of quotes, comments
+// and PostgreSQL dollar-quoted bodies, since not every driver executes
+// several statements in one call
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" && !isOnlyComments(statement) {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); {
+		end := i + 1
+		switch ch := script[i]; {
+		case ch == '\'' || ch == '"' || ch == '`':
+			end = tokenEnd(script, i+1, string(ch))
+		case strings.HasPrefix(script[i:], "--"):
+			end = tokenEnd(script, i+2, "\n")
+		case strings.HasPrefix(script[i:], "/*"):
+			end = tokenEnd(script, i+2, "*/")
+		case ch == '$' && dollarQuote.MatchString(script[i:]):
+			tag := dollarQuote.FindString(script[i:])
+			end = tokenEnd(script, i+len(tag), tag)
+		case ch == ';':
+			flush()
+			i++
+			continue
+		}
+		current.WriteString(script[i:end])
+		i = end
+	}
+	flush()
+
+	return statements
+}
+
+// tokenEnd returns the index just past the first closing at or after from,
+// or the end of the script when it is never closed
+func tokenEnd(script string, from int, closing string) int {
+	if index := strings.Index(script[from:], closing); index >= 0 {
+		return from + index + len(closing)
+	}
+	return len(script)
+}
+
+// isOnlyComments reports whether a statement contains nothing but line comments
+func isOnlyComments(statement string) bool {
+	for _, line := range strings.Split(statement, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
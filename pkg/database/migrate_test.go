@@ -0,0 +1,237 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "statements",
+			script: "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n",
+			want:   []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"},
+		},
+		{
+			name:   "no trailing semicolon",
+			script: "DROP TABLE a",
+			want:   []string{"DROP TABLE a"},
+		},
+		{
+			name:   "semicolon in single quotes",
+			script: "INSERT INTO a VALUES ('x;y'); DELETE FROM a",
+			want:   []string{"INSERT INTO a VALUES ('x;y')", "DELETE FROM a"},
+		},
+		{
+			name:   "escaped single quote",
+			script: "INSERT INTO a VALUES ('it''s;'); DELETE FROM a",
+			want:   []string{"INSERT INTO a VALUES ('it''s;')", "DELETE FROM a"},
+		},
+		{
+			name:   "semicolon in identifiers",
+			script: "CREATE TABLE \"a;b\" (id INT); CREATE TABLE `c;d` (id INT)",
+			want:   []string{"CREATE TABLE \"a;b\" (id INT)", "CREATE TABLE `c;d` (id INT)"},
+		},
+		{
+			name:   "semicolon in line comment",
+			script: "CREATE TABLE a (id INT); -- drop it; later\nDROP TABLE a;",
+			want:   []string{"CREATE TABLE a (id INT)", "-- drop it; later\nDROP TABLE a"},
+		},
+		{
+			name:   "semicolon in block comment",
+			script: "/* first; second */ CREATE TABLE a (id INT);",
+			want:   []string{"/* first; second */ CREATE TABLE a (id INT)"},
+		},
+		{
+			name:   "comments only",
+			script: "-- Up migration 1_init (sqlite)\n",
+			want:   nil,
+		},
+		{
+			name:   "dollar-quoted body",
+			script: "CREATE FUNCTION f() RETURNS trigger AS $$ BEGIN NEW.a := 1; RETURN NEW; END; $$ LANGUAGE plpgsql; SELECT 1",
+			want:   []string{"CREATE FUNCTION f() RETURNS trigger AS $$ BEGIN NEW.a := 1; RETURN NEW; END; $$ LANGUAGE plpgsql", "SELECT 1"},
+		},
+		{
+			name:   "tagged dollar-quoted body",
+			script: "DO $body$ BEGIN PERFORM 1; END $body$; SELECT 2",
+			want:   []string{"DO $body$ BEGIN PERFORM 1; END $body$", "SELECT 2"},
+		},
+		{
+			name:   "unterminated quote",
+			script: "SELECT 'a; SELECT 2",
+			want:   []string{"SELECT 'a; SELECT 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// testMigrations are two sqlite migrations, the second has two statements
+var testMigrations = fstest.MapFS{
+	"sqlite/1_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER PRIMARY KEY, note TEXT);\nINSERT INTO a (note) VALUES ('x;y');\n")},
+	"sqlite/1_create_a.down.sql": {Data: []byte("DROP TABLE a;\n")},
+	"sqlite/2_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER PRIMARY KEY);\nCREATE INDEX b_id ON b (id);\n")},
+	"sqlite/2_create_b.down.sql": {Data: []byte("DROP INDEX b_id;\nDROP TABLE b;\n")},
+	"sqlite/notes.txt":           {Data: []byte("not a migration")},
+}
+
+// openTestDB opens a sqlite database in a temporary file, so every connection shares it
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	conn, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		TranslateError: true,
+		Logger:         gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// newTestMigrator returns a migrator of the migrations in fsys
+func newTestMigrator(t *testing.T, conn *gorm.DB, fsys fstest.MapFS, lockTimeout time.Duration) *Migrator {
+	t.Helper()
+	m, err := NewMigrator(conn, SQLite, fsys, lockTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// applied returns the versions applied according to Status
+func applied(t *testing.T, m *Migrator) []int64 {
+	t.Helper()
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []int64
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			versions = append(versions, status.Version)
+		}
+	}
+	return versions
+}
+
+func TestMigratorRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	m := newTestMigrator(t, conn, testMigrations, time.Second)
+
+	if pending, err := m.Pending(ctx); err != nil || pending != 2 {
+		t.Fatalf("got %d pending, %v, want 2", pending, err)
+	}
+
+	migrations, err := m.Up(ctx)
+	if err != nil || len(migrations) != 2 {
+		t.Fatalf("got %d applied, %v, want 2", len(migrations), err)
+	}
+	if got := applied(t, m); !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Fatalf("got applied %v, want [1 2]", got)
+	}
+	var note string
+	if err := conn.Raw("SELECT note FROM a").Scan(&note).Error; err != nil || note != "x;y" {
+		t.Errorf("got note %q, %v, want x;y", note, err)
+	}
+
+	// Applying again is a no-op
+	if migrations, err := m.Up(ctx); err != nil || len(migrations) != 0 {
+		t.Fatalf("got %d applied again, %v, want 0", len(migrations), err)
+	}
+
+	migrations, err = m.Down(ctx, 1)
+	if err != nil || len(migrations) != 1 || migrations[0].Version != 2 {
+		t.Fatalf("got rolled back %+v, %v, want version 2", migrations, err)
+	}
+	if conn.Migrator().HasTable("b") || !conn.Migrator().HasTable("a") {
+		t.Error("down did not drop only table b")
+	}
+	if got := applied(t, m); !reflect.DeepEqual(got, []int64{1}) {
+		t.Fatalf("got applied %v, want [1]", got)
+	}
+
+	// A migration applied by a newer release shows as missing
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	older := fstest.MapFS{}
+	for name, file := range testMigrations {
+		if strings.HasPrefix(name, "sqlite/1_") {
+			older[name] = file
+		}
+	}
+	statuses, err := newTestMigrator(t, conn, older, time.Second).Status(ctx)
+	if err != nil || len(statuses) != 2 || !statuses[1].Missing || statuses[1].Name != "create_b" {
+		t.Fatalf("got statuses %+v, %v, want create_b missing", statuses, err)
+	}
+	if _, err := newTestMigrator(t, conn, older, time.Second).Down(ctx, 1); err == nil {
+		t.Error("rolled back a migration without its file")
+	}
+
+	if migrations, err := m.Down(ctx, 5); err != nil || len(migrations) != 2 {
+		t.Fatalf("got %d rolled back, %v, want 2", len(migrations), err)
+	}
+	if conn.Migrator().HasTable("a") {
+		t.Error("table a still exists after rolling back every migration")
+	}
+}
+
+func TestMigratorLock(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	first := newTestMigrator(t, conn, testMigrations, time.Second)
+	second := newTestMigrator(t, conn, testMigrations, 0)
+	first.owner, second.owner = "first", "second"
+
+	err := first.withLock(ctx, func() error {
+		_, err := second.Up(ctx)
+		return err
+	})
+	if !errors.Is(err, ErrMigrationLocked) {
+		t.Fatalf("got %v from the second caller, want ErrMigrationLocked", err)
+	}
+	if got := applied(t, first); len(got) != 0 {
+		t.Errorf("got applied %v while locked, want none", got)
+	}
+
+	// The lock is released once the first caller returns
+	if _, err := second.Up(ctx); err != nil {
+		t.Fatalf("got %v after the lock was released", err)
+	}
+
+	// Unlock clears a lock left behind by a dead process
+	if err := conn.Exec("INSERT INTO "+MigrationsLockTable+" (id, locked_by, locked_at) VALUES (1, 'dead', ?)", time.Now().UTC()).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := second.Down(ctx, 1); !errors.Is(err, ErrMigrationLocked) {
+		t.Fatalf("got %v with a stale lock, want ErrMigrationLocked", err)
+	}
+	if err := second.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := second.Down(ctx, 1); err != nil {
+		t.Fatalf("got %v after unlock", err)
+	}
+}
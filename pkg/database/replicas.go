@@ -0,0 +1,240 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/yorukot/go-template/pkg/config"
+	"github.com/yorukot/go-template/pkg/logger"
+	"github.com/yorukot/go-template/pkg/metrics"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+//-----------------------------------------------------------------------------
+// Read Replicas
+//-----------------------------------------------------------------------------
+
+// replicaPluginName is the name the replica set is registered under with GORM
+const replicaPluginName = "app:replicas"
+
+// sqlDriverNames maps the database types to their database/sql driver names
+var sqlDriverNames = map[string]string{
+	PostgreSQL: "pgx",
+	MySQL:      "mysql",
+	MariaDB:    "mysql",
+}
+
+type primaryContextKey struct{}
+
+// WithPrimary returns a context whose reads go to the primary, for reads
+// that must see the caller's own writes despite the replication lag.
+// Writes and transactions always use the primary.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryContextKey{}, true)
+}
+
+// usesPrimary reports whether the context was returned by WithPrimary
+func usesPrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	primary, _ := ctx.Value(primaryContextKey{}).(bool)
+	return primary
+}
+
+// replicaSet is the connection pool dbresolver sends the reads to. Each
+// query goes to the next healthy replica, or to the primary when its context
+// was returned by WithPrimary or when every replica is out of rotation. It is
+// also registered as a GORM plugin so Close can find it.
+type replicaSet struct {
+	primary  *sql.DB
+	replicas []*replica
+	next     atomic.Uint64
+	stop     context.CancelFunc
+	done     chan struct{}
+}
+
+// replica is a read replica pool and the result of its last health check
+type replica struct {
+	name    string // replica_0, replica_1, ... since the DSN holds the password
+	pool    *sql.DB
+	healthy atomic.Bool
+	checked bool // only used by check, which never runs concurrently
+}
+
+// useReplicas opens a pool per DATABASE_REPLICAS entry, routes the reads of
+// conn to them and checks their health every DATABASE_REPLICA_CHECK_INTERVAL.
+// A replica that is down at startup joins the rotation once it passes a check.
+func useReplicas(ctx context.Context, conn *gorm.DB, cfg config.DatabaseConfig) error {
+	primary, err := conn.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get SQL DB instance: %w", err)
+	}
+
+	set := &replicaSet{primary: primary, done: make(chan struct{})}
+	for i, replicaURL := range cfg.Replicas {
+		replicaConfig := cfg
+		replicaConfig.URL = replicaURL
+		name := fmt.Sprintf("replica_%d", i)
+
+		dsn, err := buildDSN(replicaConfig)
+		if err != nil {
+			set.closePools()
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		// Opening a pool does not connect, the health check does
+		pool, err := sql.Open(sqlDriverNames[cfg.Type], dsn)
+		if err != nil {
+			set.closePools()
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		pool.SetMaxIdleConns(cfg.MaxIdleConns)
+		pool.SetMaxOpenConns(cfg.MaxOpenConns)
+		pool.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		if err := metrics.RegisterDatabasePool(pool, cfg.Type+"_"+name); err != nil {
+			logger.Log.Sugar().Warnf("Failed to register %s metrics: %v", name, err)
+		}
+
+		set.replicas = append(set.replicas, &replica{name: name, pool: pool})
+	}
+
+	set.check(ctx, cfg.ReplicaCheckInterval)
+
+	if err := conn.Use(set); err != nil {
+		set.closePools()
+		return err
+	}
+	if err := conn.Use(dbresolver.Register(dbresolver.Config{Replicas: []gorm.Dialector{set.dialector(cfg.Type)}})); err != nil {
+		set.closePools()
+		return fmt.Errorf("failed to register replicas: %w", err)
+	}
+
+	runCtx, stop := context.WithCancel(context.Background())
+	set.stop = stop
+	go set.run(runCtx, cfg.ReplicaCheckInterval)
+
+	logger.Log.Sugar().Infof("Routing reads to %d replicas", len(set.replicas))
+	return nil
+}
+
+// dialector wraps the set for dbresolver
+func (s *replicaSet) dialector(dbType string) gorm.Dialector {
+	if dbType == MySQL || dbType == MariaDB {
+		// The version query would run before any replica is checked
+		return mysql.New(mysql.Config{Conn: s, SkipInitializeWithVersion: true})
+	}
+	return postgres.New(postgres.Config{Conn: s})
+}
+
+// Name implements gorm.Plugin
+func (s *replicaSet) Name() string {
+	return replicaPluginName
+}
+
+// Initialize implements gorm.Plugin, the routing is done by dbresolver
+func (s *replicaSet) Initialize(*gorm.DB) error {
+	return nil
+}
+
+// pick returns the pool the read runs on
+func (s *replicaSet) pick(ctx context.Context) *sql.DB {
+	if usesPrimary(ctx) {
+		return s.primary
+	}
+
+	start := s.next.Add(1)
+	for i := range s.replicas {
+		r := s.replicas[(start+uint64(i))%uint64(len(s.replicas))]
+		if r.healthy.Load() {
+			return r.pool
+		}
+	}
+	return s.primary
+}
+
+// PrepareContext implements gorm.ConnPool
+func (s *replicaSet) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return s.pick(ctx).PrepareContext(ctx, query)
+}
+
+// ExecContext implements gorm.ConnPool
+func (s *replicaSet) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.pick(ctx).ExecContext(ctx, query, args...)
+}
+
+// QueryContext implements gorm.ConnPool
+func (s *replicaSet) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.pick(ctx).QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext implements gorm.ConnPool
+func (s *replicaSet) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.pick(ctx).QueryRowContext(ctx, query, args...)
+}
+
+// run checks the replicas every interval until ctx is canceled
+func (s *replicaSet) run(ctx context.Context, interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.check(ctx, interval)
+		}
+	}
+}
+
+// check pings every replica, takes the failing ones out of rotation and
+// puts the recovered ones back
+func (s *replicaSet) check(ctx context.Context, timeout time.Duration) {
+	for _, r := range s.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := r.pool.PingContext(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		healthy := err == nil
+		wasHealthy := r.healthy.Swap(healthy)
+		switch {
+		case healthy && !wasHealthy:
+			logger.Log.Sugar().Infof("Database %s is healthy, added to the read rotation", r.name)
+		case !healthy && (wasHealthy || !r.checked):
+			logger.Log.Sugar().Warnf("Database %s failed its health check, removed from the read rotation: %v", r.name, err)
+		}
+		r.checked = true
+
+		up := 0.0
+		if healthy {
+			up = 1
+		}
+		metrics.DBReplicaUp.WithLabelValues(r.name).Set(up)
+	}
+}
+
+// close stops the health checks and closes the replica pools
+func (s *replicaSet) close() {
+	if s.stop != nil {
+		s.stop()
+		<-s.done
+	}
+	s.closePools()
+}
+
+// closePools closes every replica pool
+func (s *replicaSet) closePools() {
+	for _, r := range s.replicas {
+		_ = r.pool.Close()
+	}
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+//-----------------------------------------------------------------------------
+// Transactions
+//-----------------------------------------------------------------------------
+
+// Driver error codes of unique violations
+const (
+	postgresUniqueViolation = "23505"
+	mysqlDuplicateEntry     = 1062
+)
+
+type txContextKey struct{}
+
+// WithTx runs fn in a transaction on conn. The context passed to fn carries
+// the transaction, so every query made through Conn with it joins the same
+// unit of work whatever repository makes it. The transaction commits when fn
+// returns nil and rolls back when it returns an error or panics. A WithTx
+// nested in another one joins the outer transaction.
+func WithTx(ctx context.Context, conn *gorm.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	return conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	})
+}
+
+// Conn returns the transaction carried by ctx, or conn when there is none,
+// bound to ctx. Repositories query through it so they can join a WithTx.
+func Conn(ctx context.Context, conn *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return conn.WithContext(ctx)
+}
+
+// IsUniqueViolation reports whether err comes from a unique or primary key
+// constraint on any supported driver. GORM only translates the errors of the
+// statements it runs itself and drops wrapped driver errors, so the driver
+// errors are checked as well.
+func IsUniqueViolation(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == postgresUniqueViolation
+	}
+
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDuplicateEntry
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique ||
+			sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+
+	return false
+}
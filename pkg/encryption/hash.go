@@ -6,10 +6,9 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"os"
-	"strconv"
 	"strings"
 
+	"github.com/yorukot/go-template/pkg/config"
 	"golang.org/x/crypto/argon2"
 )
 
@@ -28,9 +27,8 @@ var (
 
 // Hash passsword with argon2i
 func HashPassword(password string) (string, error) {
-	memory, _ := strconv.ParseUint(os.Getenv("ARGON2_MEMORY"), 10, 32)
-	iterations, _ := strconv.ParseUint(os.Getenv("ARGON2_ITERATIONS"), 10, 32)
-	parallelism, _ := strconv.ParseUint(os.Getenv("ARGON2_PARALLELISM"), 10, 32)
+	cfg := config.Get().Argon2
+	memory, iterations, parallelism := cfg.Memory, cfg.Iterations, cfg.Parallelism
 	salt := make([]byte, 16)
 	if _, err := rand.Read(salt); err != nil {
 		return "", err
@@ -39,9 +37,9 @@ func HashPassword(password string) (string, error) {
 	hash := argon2.IDKey(
 		[]byte(password),
 		salt,
-		uint32(iterations),
-		uint32(memory),
-		uint8(parallelism),
+		iterations,
+		memory,
+		parallelism,
 		32, // Output length
 	)
 
@@ -3,9 +3,18 @@ package encryption
 import (
 	"time"
 
+	"github.com/godruoyi/go-snowflake"
+	"github.com/yorukot/go-template/pkg/config"
 	"golang.org/x/exp/rand"
 )
 
 func init() {
 	rand.Seed(uint64(time.Now().UnixNano()))
-}
\ No newline at end of file
+}
+
+// Init sets the JWT secrets and the snowflake MachineID from the config
+func Init(cfg *config.Config) {
+	JwtSecretKey = cfg.Cookie.JWTSecretKey
+	SetPreviousJwtSecretKeys(cfg.Cookie.JWTPreviousSecretKeys)
+	snowflake.SetMachineID(uint16(cfg.App.MachineID))
+}
@@ -1,24 +1,36 @@
 package encryption
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"os"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/yorukot/go-template/pkg/logger"
 )
 
 var JwtSecretKey = ""
 
-func init() {
-	JwtSecretKey = os.Getenv("JWT_SECRET_KEY")
-	if JwtSecretKey == "" {
-		logger.Log.Fatal("missing JWT secret key")
+// previousJwtSecretKeys holds the keys replaced by a rotation by key ID, they
+// only verify tokens that were signed before the rotation
+var previousJwtSecretKeys = map[string]string{}
+
+// SetPreviousJwtSecretKeys replaces the keys accepted for verification only
+func SetPreviousJwtSecretKeys(keys []string) {
+	previousJwtSecretKeys = make(map[string]string, len(keys))
+	for _, key := range keys {
+		previousJwtSecretKeys[JwtKeyID(key)] = key
 	}
 }
 
-
+// JwtKeyID returns the "kid" header of the tokens signed with the key, it is
+// a short hash so the key itself is never exposed
+func JwtKeyID(secretKey string) string {
+	sum := sha256.Sum256([]byte(secretKey))
+	return hex.EncodeToString(sum[:8])
+}
 
 // Generate new jwt token with credentials
 func GenerateNewJwtToken(id uint64, credentials []string, expiresAt time.Time) (string, error) {
@@ -36,6 +48,7 @@ func GenerateNewJwtToken(id uint64, credentials []string, expiresAt time.Time) (
 
 	// Create a new JWT access token with claims.
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = JwtKeyID(JwtSecretKey)
 
 	// Generate token.
 	t, err := token.SignedString([]byte(JwtSecretKey))
@@ -57,8 +70,8 @@ func ParseAndValidateJWT(tokenString string) (jwt.MapClaims, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method")
 		}
-		return []byte(JwtSecretKey), nil
-	})
+		return jwtVerificationKey(token)
+	}, jwt.WithJSONNumber()) // IDs are above 2^53, they don't survive a float64
 
 	// Validate token and check for errors
 	if err != nil || !token.Valid {
@@ -72,14 +85,35 @@ func ParseAndValidateJWT(tokenString string) (jwt.MapClaims, error) {
 	}
 
 	// Verify the token expiration time
-	expiresAtFloat, ok := claims["exp"].(float64)
-	if !ok {
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
 		return nil, fmt.Errorf("invalid expiration time datatype")
 	}
-	expiresAt := int64(expiresAtFloat)
-	if time.Now().Unix() >= expiresAt {
+	if !time.Now().Before(expiresAt.Time) {
 		return nil, fmt.Errorf("token expired")
 	}
 
 	return claims, nil
-}
\ No newline at end of file
+}
+
+// JwtSubject returns the user ID of claims returned by ParseAndValidateJWT
+func JwtSubject(claims jwt.MapClaims) (uint64, error) {
+	sub, ok := claims["sub"].(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("invalid subject datatype")
+	}
+	return strconv.ParseUint(sub.String(), 10, 64)
+}
+
+// jwtVerificationKey picks the key by the "kid" header, tokens signed before
+// key IDs were added have none and use the current key
+func jwtVerificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" || kid == JwtKeyID(JwtSecretKey) {
+		return []byte(JwtSecretKey), nil
+	}
+	if key, ok := previousJwtSecretKeys[kid]; ok {
+		return []byte(key), nil
+	}
+	return nil, fmt.Errorf("unknown key id")
+}
@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check reports whether a dependency is usable, it must honour ctx cancellation
+type Check func(ctx context.Context) error
+
+// Component status values
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// ComponentStatus is the result of a single check
+type ComponentStatus struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the result of every registered check
+type Report struct {
+	Ready      bool                       `json:"ready"`
+	Draining   bool                       `json:"draining"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+var (
+	mu       sync.RWMutex
+	checks   = map[string]Check{}
+	draining atomic.Bool
+)
+
+// Register adds a readiness check, registering the same name again replaces it
+func Register(name string, check Check) {
+	mu.Lock()
+	defer mu.Unlock()
+	checks[name] = check
+}
+
+// SetDraining marks the process as draining so readiness fails while in-flight requests finish
+func SetDraining(value bool) {
+	draining.Store(value)
+}
+
+// IsDraining reports whether the process is draining
+func IsDraining() bool {
+	return draining.Load()
+}
+
+// Names returns the registered check names in alphabetical order
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run executes every registered check concurrently, each bounded by timeout
+func Run(ctx context.Context, timeout time.Duration) Report {
+	mu.RLock()
+	registered := make(map[string]Check, len(checks))
+	for name, check := range checks {
+		registered[name] = check
+	}
+	mu.RUnlock()
+
+	report := Report{
+		Ready:      !IsDraining(),
+		Draining:   IsDraining(),
+		Components: make(map[string]ComponentStatus, len(registered)),
+	}
+
+	var wg sync.WaitGroup
+	var resultMu sync.Mutex
+	for name, check := range registered {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			status := runCheck(ctx, check, timeout)
+
+			resultMu.Lock()
+			defer resultMu.Unlock()
+			report.Components[name] = status
+			if status.Status != StatusUp {
+				report.Ready = false
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// runCheck executes a check with a timeout and measures its latency
+func runCheck(ctx context.Context, check Check, timeout time.Duration) ComponentStatus {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- check(ctx)
+	}()
+
+	// Don't wait on checks that ignore the context
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	status := ComponentStatus{
+		Status:    StatusUp,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
@@ -0,0 +1,117 @@
+// Package imaging decodes untrusted images and re-encodes them at fixed
+// square sizes. Re-encoding keeps only the pixels, so EXIF and every other
+// metadata block of the upload is dropped.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// ErrTooLarge is returned by Decode for images wider or taller than allowed
+var ErrTooLarge = errors.New("image dimensions are too large")
+
+// jpegQuality is the quality of the re-encoded JPEG images
+const jpegQuality = 85
+
+// Decode reads a JPEG, PNG or GIF image, the first frame of a GIF. The
+// dimensions are checked from the header before the pixels are decoded, so a
+// small file can't claim a huge image and exhaust the memory.
+func Decode(data []byte, maxDimension int) (image.Image, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image header: %w", err)
+	}
+	if cfg.Width < 1 || cfg.Height < 1 || cfg.Width > maxDimension || cfg.Height > maxDimension {
+		return nil, "", ErrTooLarge
+	}
+
+	var img image.Image
+	switch format {
+	case "jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	default:
+		return nil, "", fmt.Errorf("unsupported image format %s", format)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, format, nil
+}
+
+// Square crops the center square of img and scales it to size x size. Each
+// destination pixel averages the source pixels it covers, which keeps the
+// downscaled images smooth without an external dependency.
+func Square(img image.Image, size int) *image.NRGBA {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+
+	// Work on premultiplied RGBA so transparent pixels don't bleed their color
+	src := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(src, src.Bounds(), img, crop.Min, draw.Src)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := y*side/size, max((y+1)*side/size, y*side/size+1)
+		for x := 0; x < size; x++ {
+			x0, x1 := x*side/size, max((x+1)*side/size, x*side/size+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				offset := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(src.Pix[offset])
+					g += uint64(src.Pix[offset+1])
+					b += uint64(src.Pix[offset+2])
+					a += uint64(src.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: uint8(a / n)})
+		}
+	}
+	return dst
+}
+
+// Output formats of Encode
+const (
+	PNG  = "png"
+	JPEG = "jpeg"
+)
+
+// FormatFor returns PNG for images with transparent pixels and JPEG otherwise
+func FormatFor(img *image.NRGBA) string {
+	if img.Opaque() {
+		return JPEG
+	}
+	return PNG
+}
+
+// Encode writes img in the format, PNG or JPEG
+func Encode(w io.Writer, img *image.NRGBA, format string) error {
+	switch format {
+	case PNG:
+		return png.Encode(w, img)
+	case JPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	default:
+		return fmt.Errorf("unsupported output format %s", format)
+	}
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// FromContext returns a child of Log carrying the request context fields.
+// For a *gin.Context these are the request ID, the trace and span IDs, the
+// matched route template, the authenticated user ID and the client IP, for
+// any other context only the request, trace and span IDs are attached.
+func FromContext(ctx context.Context) *zap.Logger {
+	if ctx == nil {
+		return Log
+	}
+
+	fields := make([]zap.Field, 0, 6)
+	if requestID := RequestID(ctx); requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
+	}
+	fields = append(fields, TraceFields(ctx)...)
+
+	if c, ok := ctx.(*gin.Context); ok {
+		if route := c.FullPath(); route != "" {
+			fields = append(fields, zap.String("route", route))
+		}
+		if userID, exists := c.Get("userID"); exists {
+			fields = append(fields, zap.Any("user_id", userID))
+		}
+		if c.Request != nil {
+			fields = append(fields, zap.String("client_ip", c.ClientIP()))
+		}
+	}
+
+	return Log.With(fields...)
+}
+
+// TraceFields returns the trace and span IDs of the span in ctx so logs can be linked to traces
+func TraceFields(ctx context.Context) []zap.Field {
+	if c, ok := ctx.(*gin.Context); ok {
+		if c.Request == nil {
+			return nil
+		}
+		ctx = c.Request.Context()
+	}
+
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("trace_id", spanContext.TraceID().String()),
+		zap.String("span_id", spanContext.SpanID().String()),
+	}
+}
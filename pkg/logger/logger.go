@@ -3,20 +3,126 @@ package logger
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/pkg/config"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 var Log *zap.Logger
 
-// Initialize the logger during package initialization
+// Level is the runtime adjustable level shared by every logger derived from Log
+var Level zap.AtomicLevel
+
+// Supported log outputs
+const (
+	OutputStdout  = "stdout"  // JSON lines on stdout
+	OutputConsole = "console" // Human readable lines on stdout
+	OutputFile    = "file"    // JSON lines in a size and age rotated file
+)
+
+// Until Init runs, log JSON lines at info level to stdout
 func init() {
-	// Create the logger instance based on environment
-	Log = zap.Must(zap.NewProduction())
-	if os.Getenv("GIN_MODE") == "debug" {
-		Log = zap.Must(zap.NewDevelopment())
+	Level = zap.NewAtomicLevel()
+	core := zapcore.NewCore(newEncoder(OutputStdout, false), zapcore.Lock(os.Stdout), Level)
+	Log = zap.New(newRedactCore(core), zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+}
+
+// Init rebuilds Log from the log section of the config, it is called once by
+// the application bootstrap before anything else logs
+func Init(cfg *config.Config) {
+	debug := cfg.App.GinMode == "debug"
+
+	level, output := cfg.Log.Level, cfg.Log.Output
+	if level == "" {
+		level = "info"
+		if debug {
+			level = "debug"
+		}
+	}
+	if output == "" {
+		output = OutputStdout
+		if debug {
+			output = OutputConsole
+		}
+	}
+
+	if err := SetLevel(level); err != nil {
+		Level.SetLevel(zapcore.InfoLevel)
+	}
+
+	core := zapcore.NewCore(newEncoder(output, debug), newWriteSyncer(output, cfg.Log), Level)
+
+	options := []zap.Option{zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel)}
+	if debug {
+		options = append(options, zap.Development())
 	}
+
+	Log = zap.New(wrapCore(core, cfg.Log), options...)
+}
+
+// wrapCore redacts the sensitive fields written to core and samples the
+// entries when LOG_SAMPLING_INITIAL is set. The redaction sits below the
+// sampler, so it runs on the written entries only and never bypasses it.
+func wrapCore(core zapcore.Core, cfg config.LogConfig) zapcore.Core {
+	core = newRedactCore(core)
+
+	// Sample repeated messages so a flood of requests can't drown the log
+	// pipeline, access lines and errors are kept
+	if cfg.SamplingInitial > 0 {
+		core = newSampleCore(core, cfg.SamplingInitial, cfg.SamplingThereafter)
+	}
+	return core
+}
+
+// newEncoder returns a console encoder for console output and a JSON encoder otherwise
+func newEncoder(output string, debug bool) zapcore.Encoder {
+	if output == OutputConsole {
+		config := zap.NewProductionEncoderConfig()
+		if debug {
+			config = zap.NewDevelopmentEncoderConfig()
+		}
+		config.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		return zapcore.NewConsoleEncoder(config)
+	}
+
+	config := zap.NewProductionEncoderConfig()
+	config.EncodeTime = zapcore.ISO8601TimeEncoder
+	config.EncodeLevel = zapcore.LowercaseLevelEncoder
+	return zapcore.NewJSONEncoder(config)
+}
+
+// newWriteSyncer returns the destination for the configured output
+func newWriteSyncer(output string, cfg config.LogConfig) zapcore.WriteSyncer {
+	if output != OutputFile {
+		return zapcore.Lock(os.Stdout)
+	}
+
+	return zapcore.AddSync(&lumberjack.Logger{
+		Filename:   cfg.FilePath,
+		MaxSize:    cfg.FileMaxSize,
+		MaxAge:     cfg.FileMaxAge,
+		MaxBackups: cfg.FileMaxBackups,
+		Compress:   true,
+	})
+}
+
+// SetLevel changes the level of every logger derived from Log at runtime
+func SetLevel(level string) error {
+	parsed, err := zapcore.ParseLevel(strings.ToLower(level))
+	if err != nil {
+		return fmt.Errorf("invalid log level %q", level)
+	}
+	Level.SetLevel(parsed)
+	return nil
+}
+
+// GetLevel returns the current log level
+func GetLevel() string {
+	return Level.Level().String()
 }
 
 // LogError handles error logging with context
@@ -26,7 +132,6 @@ func LogError(c *gin.Context, err error, message string, extraFields map[string]
 		zap.String("error", fmt.Sprintf("%v", err)),
 		zap.String("method", c.Request.Method),
 		zap.String("url", c.Request.URL.Path),
-		zap.String("client_ip", c.ClientIP()),
 	}
 
 	// Add extra fields if provided
@@ -34,6 +139,6 @@ func LogError(c *gin.Context, err error, message string, extraFields map[string]
 		fields = append(fields, zap.Any(key, value))
 	}
 
-	// Log the error
-	Log.Error(message, fields...)
-}
\ No newline at end of file
+	// Log the error with the request ID, route, user ID and client IP
+	FromContext(c).Error(message, fields...)
+}
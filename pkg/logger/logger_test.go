@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/yorukot/go-template/pkg/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newTestLogger(cfg config.LogConfig) (*zap.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return zap.New(wrapCore(core, cfg)), logs
+}
+
+func TestSamplingKeepsInitialEntries(t *testing.T) {
+	log, logs := newTestLogger(config.LogConfig{SamplingInitial: 3, SamplingThereafter: 1000})
+
+	for i := 0; i < 100; i++ {
+		log.Info("cache miss")
+	}
+
+	if logs.Len() != 3 {
+		t.Fatalf("got %d entries, want 3", logs.Len())
+	}
+}
+
+func TestSamplingKeepsErrorsAndAccessLines(t *testing.T) {
+	log, logs := newTestLogger(config.LogConfig{SamplingInitial: 1, SamplingThereafter: 1000})
+
+	for i := 0; i < 50; i++ {
+		log.Error("database unavailable")
+		log.Info(AccessMessage)
+	}
+
+	if got := logs.FilterMessage("database unavailable").Len(); got != 50 {
+		t.Fatalf("got %d error entries, want 50", got)
+	}
+	if got := logs.FilterMessage(AccessMessage).Len(); got != 50 {
+		t.Fatalf("got %d access entries, want 50", got)
+	}
+}
+
+func TestSamplingDisabled(t *testing.T) {
+	log, logs := newTestLogger(config.LogConfig{})
+
+	for i := 0; i < 100; i++ {
+		log.Info("cache miss")
+	}
+
+	if logs.Len() != 100 {
+		t.Fatalf("got %d entries, want 100", logs.Len())
+	}
+}
+
+func TestRedactsSensitiveFields(t *testing.T) {
+	log, logs := newTestLogger(config.LogConfig{SamplingInitial: 10, SamplingThereafter: 100})
+
+	log.With(zap.String("password", "hunter2")).Info("signup", zap.String("refresh_token", "secret"), zap.String("email", "a@example.com"))
+
+	entries := logs.AllUntimed()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["password"] != RedactedValue || fields["refresh_token"] != RedactedValue {
+		t.Fatalf("sensitive fields not redacted: %v", fields)
+	}
+	if fields["email"] != "a@example.com" {
+		t.Fatalf("email = %v, want a@example.com", fields["email"])
+	}
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactedValue replaces the value of sensitive fields
+const RedactedValue = "[REDACTED]"
+
+// sensitiveKeys are matched case-insensitively against field and map keys
+var sensitiveKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"cookie",
+	"authorization",
+	"api_key",
+	"apikey",
+	"x-csrf-token",
+}
+
+// IsSensitiveKey reports whether a field with this key must never be logged
+func IsSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHeaders returns a copy of the headers with sensitive values replaced
+func RedactHeaders(headers http.Header) http.Header {
+	redacted := make(http.Header, len(headers))
+	for key, values := range headers {
+		if IsSensitiveKey(key) {
+			redacted[key] = []string{RedactedValue}
+			continue
+		}
+		redacted[key] = values
+	}
+	return redacted
+}
+
+// redactCore wraps a zapcore.Core and redacts sensitive fields before they are encoded
+type redactCore struct {
+	zapcore.Core
+}
+
+// newRedactCore wraps the core that writes the entries, so every logger
+// derived from Log is redacted. It adds itself to the checked entries like a
+// writing core, so it must sit below any core that filters entries.
+func newRedactCore(core zapcore.Core) zapcore.Core {
+	return &redactCore{Core: core}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+// redactFields replaces sensitive fields, including sensitive keys inside maps and headers
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		if IsSensitiveKey(field.Key) {
+			redacted[i] = zap.String(field.Key, RedactedValue)
+			continue
+		}
+
+		switch value := field.Interface.(type) {
+		case http.Header:
+			redacted[i] = zap.Any(field.Key, RedactHeaders(value))
+		case map[string]interface{}:
+			redacted[i] = zap.Any(field.Key, redactMap(value))
+		case map[string]string:
+			redacted[i] = zap.Any(field.Key, redactStringMap(value))
+		default:
+			redacted[i] = field
+		}
+	}
+	return redacted
+}
+
+// redactMap returns a copy of the map with sensitive values replaced, recursing into nested maps
+func redactMap(values map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if IsSensitiveKey(key) {
+			redacted[key] = RedactedValue
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			value = redactMap(nested)
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// redactStringMap returns a copy of the map with sensitive values replaced
+func redactStringMap(values map[string]string) map[string]string {
+	redacted := make(map[string]string, len(values))
+	for key, value := range values {
+		if IsSensitiveKey(key) {
+			value = RedactedValue
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader is the header used to receive and echo the request ID
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID stored in ctx, or an empty string.
+// It accepts a *gin.Context as well as the request's context.Context, so
+// database and outbound HTTP calls can tag their logs with it.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if c, ok := ctx.(*gin.Context); ok {
+		if c.Request == nil {
+			return ""
+		}
+		ctx = c.Request.Context()
+	}
+
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
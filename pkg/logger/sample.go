@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// AccessMessage is the message of the access log lines, which are never sampled
+const AccessMessage = "HTTP Request"
+
+// sampleCore samples repeated entries, except the access log lines and the
+// entries at error level and above which are always written
+type sampleCore struct {
+	zapcore.Core
+	sampled zapcore.Core
+}
+
+// newSampleCore samples the entries of core per message, first then every thereafter each second
+func newSampleCore(core zapcore.Core, first int, thereafter int) zapcore.Core {
+	return &sampleCore{
+		Core:    core,
+		sampled: zapcore.NewSamplerWithOptions(core, time.Second, first, thereafter),
+	}
+}
+
+func (c *sampleCore) With(fields []zapcore.Field) zapcore.Core {
+	return &sampleCore{Core: c.Core.With(fields), sampled: c.sampled.With(fields)}
+}
+
+func (c *sampleCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level >= zapcore.ErrorLevel || entry.Message == AccessMessage {
+		return c.Core.Check(entry, checked)
+	}
+	return c.sampled.Check(entry, checked)
+}
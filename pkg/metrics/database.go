@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+// DBQueryDuration observes GORM statement latency by operation and table
+var DBQueryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "db_query_duration_seconds",
+	Help:    "Database query latency in seconds.",
+	Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"operation", "table", "status"})
+
+// DBReplicaUp reports whether each read replica passes its health check
+var DBReplicaUp = factory.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "db_replica_up",
+	Help: "Whether the read replica is in rotation (1) or failed its health check (0).",
+}, []string{"replica"})
+
+const startTimeKey = "metrics:start_time"
+
+// RegisterDatabase instruments GORM callbacks with query durations and
+// exports the sql.DBStats of the connection pool
+func RegisterDatabase(db *gorm.DB, name string) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if err := RegisterDatabasePool(sqlDB, name); err != nil {
+		return err
+	}
+
+	callback := db.Callback()
+	registrations := []error{
+		callback.Create().Before("gorm:create").Register("metrics:before_create", beforeQuery),
+		callback.Create().After("gorm:create").Register("metrics:after_create", afterQuery("create")),
+		callback.Query().Before("gorm:query").Register("metrics:before_query", beforeQuery),
+		callback.Query().After("gorm:query").Register("metrics:after_query", afterQuery("query")),
+		callback.Update().Before("gorm:update").Register("metrics:before_update", beforeQuery),
+		callback.Update().After("gorm:update").Register("metrics:after_update", afterQuery("update")),
+		callback.Delete().Before("gorm:delete").Register("metrics:before_delete", beforeQuery),
+		callback.Delete().After("gorm:delete").Register("metrics:after_delete", afterQuery("delete")),
+		callback.Row().Before("gorm:row").Register("metrics:before_row", beforeQuery),
+		callback.Row().After("gorm:row").Register("metrics:after_row", afterQuery("row")),
+		callback.Raw().Before("gorm:raw").Register("metrics:before_raw", beforeQuery),
+		callback.Raw().After("gorm:raw").Register("metrics:after_raw", afterQuery("raw")),
+	}
+	for _, err := range registrations {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// beforeQuery records when the statement started
+func beforeQuery(db *gorm.DB) {
+	db.InstanceSet(startTimeKey, time.Now())
+}
+
+// afterQuery observes the statement duration
+func afterQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startTimeKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		status := "ok"
+		if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+			status = "error"
+		}
+
+		DBQueryDuration.WithLabelValues(operation, db.Statement.Table, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RegisterDatabasePool exports the sql.DBStats of a connection pool, such as
+// a read replica that GORM does not own
+func RegisterDatabasePool(sqlDB *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(sqlDB, name))
+}
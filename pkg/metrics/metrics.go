@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Registry holds every application metric, it is served by the /metrics endpoint
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+//-----------------------------------------------------------------------------
+// HTTP Metrics
+//-----------------------------------------------------------------------------
+
+var (
+	// HTTPRequests counts handled requests by method, route template and status
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration observes request latency by method, route template and status
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+//-----------------------------------------------------------------------------
+// Auth Metrics
+//-----------------------------------------------------------------------------
+
+var (
+	// AuthLogins counts successful logins
+	AuthLogins = factory.NewCounter(prometheus.CounterOpts{
+		Name: "auth_logins_total",
+		Help: "Total number of successful logins.",
+	})
+
+	// AuthLoginFailures counts failed logins by reason
+	AuthLoginFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_login_failures_total",
+		Help: "Total number of failed logins.",
+	}, []string{"reason"})
+
+	// AuthSignups counts successful signups
+	AuthSignups = factory.NewCounter(prometheus.CounterOpts{
+		Name: "auth_signups_total",
+		Help: "Total number of successful signups.",
+	})
+
+	// AuthRefreshes counts access tokens issued from a refresh token
+	AuthRefreshes = factory.NewCounter(prometheus.CounterOpts{
+		Name: "auth_refreshes_total",
+		Help: "Total number of access tokens refreshed.",
+	})
+)
+
+//-----------------------------------------------------------------------------
+// Account Metrics
+//-----------------------------------------------------------------------------
+
+var (
+	// AccountDeletions counts accounts deleted by their owner
+	AccountDeletions = factory.NewCounter(prometheus.CounterOpts{
+		Name: "account_deletions_total",
+		Help: "Total number of accounts deleted by their owner.",
+	})
+
+	// AccountRestores counts deleted accounts restored by logging in
+	AccountRestores = factory.NewCounter(prometheus.CounterOpts{
+		Name: "account_restores_total",
+		Help: "Total number of deleted accounts restored during the grace period.",
+	})
+
+	// AccountPurges counts deleted accounts removed for good
+	AccountPurges = factory.NewCounter(prometheus.CounterOpts{
+		Name: "account_purges_total",
+		Help: "Total number of deleted accounts purged after the grace period.",
+	})
+
+	// UserExports counts personal data exports by result: requested, throttled, completed or failed
+	UserExports = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "user_exports_total",
+		Help: "Total number of personal data exports by result.",
+	}, []string{"result"})
+
+	// Uploads counts the uploads through signed URLs by result: requested, completed, rejected or expired
+	Uploads = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "uploads_total",
+		Help: "Total number of uploads through signed URLs by result.",
+	}, []string{"result"})
+)
+
+//-----------------------------------------------------------------------------
+// Job Metrics
+//-----------------------------------------------------------------------------
+
+var (
+	// JobRuns counts background job runs by job and result
+	JobRuns = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "job_runs_total",
+		Help: "Total number of background job runs.",
+	}, []string{"job", "result"})
+
+	// JobDuration observes the duration of background job runs
+	JobDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "job_duration_seconds",
+		Help:    "Background job run duration in seconds.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+	}, []string{"job"})
+)
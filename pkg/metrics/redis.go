@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisPoolCollector exports the connection pool stats of a Redis client
+type redisPoolCollector struct {
+	client *redis.Client
+
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+// RegisterRedis exports the pool stats of the Redis client under the given name
+func RegisterRedis(client *redis.Client, name string) error {
+	labels := prometheus.Labels{"client": name}
+	return Registry.Register(&redisPoolCollector{
+		client:     client,
+		hits:       prometheus.NewDesc("redis_pool_hits_total", "Number of times a free connection was found in the pool.", nil, labels),
+		misses:     prometheus.NewDesc("redis_pool_misses_total", "Number of times a free connection was not found in the pool.", nil, labels),
+		timeouts:   prometheus.NewDesc("redis_pool_timeouts_total", "Number of times a wait timeout occurred.", nil, labels),
+		totalConns: prometheus.NewDesc("redis_pool_total_connections", "Number of total connections in the pool.", nil, labels),
+		idleConns:  prometheus.NewDesc("redis_pool_idle_connections", "Number of idle connections in the pool.", nil, labels),
+		staleConns: prometheus.NewDesc("redis_pool_stale_connections_total", "Number of stale connections removed from the pool.", nil, labels),
+	})
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.staleConns
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/app/repository"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+// IsAdmin is a middleware to check if the authorized user is an admin.
+// It must run after IsAuthorized. The flag is read from the database on
+// every request so revoking admin rights takes effect immediately.
+func IsAdmin(users repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			utils.FullyResponse(c, 403, "UserID not found in context", utils.ErrUserIDNotFound, nil)
+			c.Abort()
+			return
+		}
+
+		user, err := users.GetByID(c.Request.Context(), userID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && user.DeletedAt != nil) {
+			utils.FullyResponse(c, 403, "User not found", utils.ErrPermissionDenied, nil)
+			c.Abort()
+			return
+		} else if err != nil {
+			utils.ServerErrorResponse(c, 500, "Error retrieving user data", utils.ErrGetData, err)
+			c.Abort()
+			return
+		}
+
+		// The access token of a disabled account stays valid until it expires
+		if user.DisabledAt != nil {
+			utils.FullyResponse(c, 403, "Account is disabled", utils.ErrAccountDisabled, nil)
+			c.Abort()
+			return
+		}
+
+		if !user.IsAdmin {
+			utils.FullyResponse(c, 403, "Admin permission required", utils.ErrPermissionDenied, nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/pkg/config"
+	"github.com/yorukot/go-template/pkg/logger"
+	"github.com/yorukot/go-template/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// CORS is a middleware that applies the cross-origin policy. Allowed origins
+// may be exact ("https://app.example.com"), wildcard subdomains
+// ("https://*.example.com") or "*", and default to BASE_URL. Requests from
+// origins outside the allowed list are rejected with 403 and logged. An
+// origin only allowed by "*" never gets credentials, config check refuses
+// that combination too.
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	allowedOrigins := cfg.AllowedOrigins
+	if len(allowedOrigins) == 0 {
+		allowedOrigins = []string{utils.FrontendURl}
+	}
+	allowMethods := strings.Join(cfg.AllowedMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || isSameOrigin(c, origin) {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Origin")
+
+		allowed, wildcard := isOriginAllowed(allowedOrigins, origin)
+		if !allowed {
+			logger.FromContext(c).Warn("CORS origin rejected",
+				zap.String("origin", origin),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+			)
+			utils.FullyResponse(c, 403, "Origin not allowed", utils.ErrOriginNotAllowed, nil)
+			c.Abort()
+			return
+		}
+
+		// Browsers refuse a wildcard origin on credentialed requests, so always echo it
+		c.Header("Access-Control-Allow-Origin", origin)
+		if cfg.AllowCredentials && !wildcard {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		// Handle preflight requests
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Header("Access-Control-Allow-Methods", allowMethods)
+			c.Header("Access-Control-Allow-Headers", allowHeaders)
+			c.Header("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		if exposeHeaders != "" {
+			c.Header("Access-Control-Expose-Headers", exposeHeaders)
+		}
+
+		c.Next()
+	}
+}
+
+// isSameOrigin reports whether the origin matches the scheme and the host
+// serving the request, the scheme comes from X-Forwarded-Proto behind a proxy
+func isSameOrigin(c *gin.Context, origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return strings.EqualFold(u.Scheme, scheme) && strings.EqualFold(u.Host, c.Request.Host)
+}
+
+// isOriginAllowed checks the origin against the allowed list, supporting
+// wildcard subdomains. It also reports whether only a "*" entry allowed it.
+func isOriginAllowed(allowedOrigins []string, origin string) (allowed bool, wildcard bool) {
+	origin = strings.ToLower(origin)
+	for _, entry := range allowedOrigins {
+		entry = strings.ToLower(strings.TrimSuffix(entry, "/"))
+		if entry == origin {
+			return true, false
+		}
+		if entry == "*" {
+			wildcard = true
+			continue
+		}
+
+		// "https://*.example.com" matches "https://app.example.com" but not "https://example.com"
+		scheme, host, found := strings.Cut(entry, "://*.")
+		if !found {
+			continue
+		}
+		prefix := scheme + "://"
+		if strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, "."+host) &&
+			len(origin) > len(prefix)+len(host)+1 {
+			return true, false
+		}
+	}
+	return wildcard, wildcard
+}
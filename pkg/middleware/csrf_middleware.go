@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/pkg/encryption"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+const (
+	// CSRFCookieName is the cookie holding the double-submit token
+	CSRFCookieName = "csrf_token"
+	// CSRFHeaderName is the header the client must echo the token in
+	CSRFHeaderName = "X-CSRF-Token"
+	// csrfTokenLength is the number of url safe characters in a token, they
+	// need no escaping so the frontend can echo the cookie value unchanged
+	csrfTokenLength = 64
+)
+
+// CSRF is a double-submit-token middleware for cookie-authenticated requests.
+// Safe methods receive a csrf_token cookie if they don't have one yet, and
+// state-changing methods must send the same value in the X-CSRF-Token header.
+// Requests authenticated with an Authorization: Bearer header are exempt
+// because browsers never attach that header automatically.
+func CSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isBearerRequest(c) {
+			c.Next()
+			return
+		}
+
+		cookieToken, _ := c.Cookie(CSRFCookieName)
+
+		if isSafeMethod(c.Request.Method) {
+			if cookieToken == "" {
+				var err error
+				cookieToken, err = encryption.RandStringRunes(csrfTokenLength, true)
+				if err != nil {
+					utils.ServerErrorResponse(c, 500, "Error generate csrf token", utils.ErrGenerateToken, err)
+					c.Abort()
+					return
+				}
+				// The cookie must be readable by the frontend so it can echo it back
+				utils.SetCookie(c, CSRFCookieName, cookieToken, 0, false)
+			}
+			c.Set("csrfToken", cookieToken)
+			c.Next()
+			return
+		}
+
+		headerToken := c.GetHeader(CSRFHeaderName)
+		if cookieToken == "" || headerToken == "" ||
+			subtle.ConstantTimeCompare([]byte(cookieToken), []byte(headerToken)) != 1 {
+			utils.FullyResponse(c, 403, "Invalid CSRF token", utils.ErrCSRFTokenInvalid, nil)
+			c.Abort()
+			return
+		}
+
+		c.Set("csrfToken", cookieToken)
+		c.Next()
+	}
+}
+
+// isSafeMethod reports whether the HTTP method is not expected to change state
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// isBearerRequest reports whether the request authenticates with an Authorization: Bearer header
+func isBearerRequest(c *gin.Context) bool {
+	return bearerToken(c) != ""
+}
+
+// bearerToken returns the token from the Authorization: Bearer header, if any
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
@@ -9,16 +9,20 @@ import (
 // IsAuthorized is a middleware to check if the user is authorized
 func IsAuthorized() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Retrieve the JWT token from the cookie
-		cookie, err := c.Request.Cookie("access_token")
-		if err != nil || cookie.Value == "" {
-			utils.FullyResponse(c, 403, "Authorization token is empty.", "authentication_key_not_found", nil)
-			c.Abort()
-			return
+		// Retrieve the JWT token from the Authorization header or the cookie
+		token := bearerToken(c)
+		if token == "" {
+			cookie, err := c.Request.Cookie("access_token")
+			if err != nil || cookie.Value == "" {
+				utils.FullyResponse(c, 403, "Authorization token is empty.", "authentication_key_not_found", nil)
+				c.Abort()
+				return
+			}
+			token = cookie.Value
 		}
 
 		// Parse and validate the JWT token
-		claims, err := encryption.ParseAndValidateJWT(cookie.Value)
+		claims, err := encryption.ParseAndValidateJWT(token)
 		if err != nil {
 			utils.FullyResponse(c, 403, err.Error(), utils.ErrUnauthorized, nil)
 			c.Abort()
@@ -26,15 +30,14 @@ func IsAuthorized() gin.HandlerFunc {
 		}
 
 		// Retrieve the user ID (subject) from the claims
-		userIDFloat, ok := claims["sub"].(float64)
-		if !ok {
+		userID, err := encryption.JwtSubject(claims)
+		if err != nil {
 			utils.FullyResponse(c, 403, "UserID error", utils.ErrUnauthorized, nil)
 			c.Abort()
 			return
 		}
-		userID := uint64(userIDFloat)
 
-		_, ok = claims["pedding"].(bool)
+		_, ok := claims["pedding"].(bool)
 		if ok {
 			utils.FullyResponse(c, 403, "Please verify email first", utils.ErrUnauthorized, nil)
 			c.Abort()
@@ -65,12 +68,11 @@ func IsPeddingVerify() gin.HandlerFunc {
 		}
 
 		// Retrieve the user ID (subject) from the claims
-		userIDFloat, ok := claims["sub"].(float64)
-		if !ok {
+		userID, err := encryption.JwtSubject(claims)
+		if err != nil {
 			c.Next()
 			return
 		}
-		userID := uint64(userIDFloat)
 
 		pedding, ok := claims["pedding"].(bool)
 		if !ok {
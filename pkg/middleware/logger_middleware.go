@@ -24,12 +24,11 @@ func CustomLogger() gin.HandlerFunc {
 		statusCode := c.Writer.Status()
 
 		// Log the request details
-		logger.Log.Info("HTTP Request",
+		logger.FromContext(c).Info(logger.AccessMessage,
 			zap.String("timestamp", time.Now().Format("2006/01/02 - 15:04:05")),
 			zap.Int("status_code", statusCode),
 			zap.String("method", c.Request.Method),
 			zap.Duration("latency", latency),
-			zap.String("client_ip", c.ClientIP()),
 			zap.String("path", c.Request.URL.Path),
 		)
 	}
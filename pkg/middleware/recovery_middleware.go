@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime/debug"
+	"strings"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/pkg/logger"
+	"github.com/yorukot/go-template/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// Recovery is a middleware that recovers from panics in handlers, logs the
+// stack trace and responds with the standard JSON error envelope
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			logger.FromContext(c).Error("Panic recovered",
+				zap.Any("panic", recovered),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.ByteString("stack", debug.Stack()),
+			)
+
+			// The client is gone, there is nobody to respond to
+			if isBrokenPipe(recovered) {
+				c.Abort()
+				return
+			}
+
+			// Part of the response was already sent, we can't replace it
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+
+			message := "Internal server error"
+			if gin.Mode() != gin.ReleaseMode {
+				message = fmt.Sprintf("Internal server error: %v", recovered)
+			}
+
+			utils.FullyResponse(c, 500, message, utils.ErrInternal, nil)
+			c.Abort()
+		}()
+
+		c.Next()
+	}
+}
+
+// isBrokenPipe reports whether the panic was caused by the client closing the connection
+func isBrokenPipe(recovered interface{}) bool {
+	err, ok := recovered.(error)
+	if !ok {
+		return false
+	}
+
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		var syscallErr *os.SyscallError
+		if errors.As(opErr.Err, &syscallErr) {
+			message := strings.ToLower(syscallErr.Error())
+			return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/pkg/encryption"
+	"github.com/yorukot/go-template/pkg/logger"
+	"github.com/yorukot/go-template/pkg/utils"
+)
+
+// maxRequestIDLength bounds client supplied request IDs so they can't bloat the logs
+const maxRequestIDLength = 128
+
+// RequestID is a middleware that accepts the X-Request-ID header or generates
+// a new snowflake ID, stores it in the gin and request contexts and echoes it
+// in the response header
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(logger.RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = utils.Uint64ToStr(encryption.GenerateID())
+		}
+
+		c.Set("requestID", requestID)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+		c.Header(logger.RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// isValidRequestID only accepts short IDs made of printable ASCII characters
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < 0x21 || requestID[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
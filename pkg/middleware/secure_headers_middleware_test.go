@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNoStoreUnder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	api := engine.Group("/api/v1")
+	r := api.Group("")
+	r.Use(NoStoreUnder(api.BasePath() + "/auth"))
+	r.Use(CSRF())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.POST("/auth/login", ok)
+	r.GET("/authors", ok)
+	r.GET("/user/profile", ok)
+
+	tests := []struct {
+		method  string
+		path    string
+		status  int
+		noStore bool
+	}{
+		{method: http.MethodPost, path: "/api/v1/auth/login", status: http.StatusForbidden, noStore: true}, // rejected by CSRF
+		{method: http.MethodGet, path: "/api/v1/authors", status: http.StatusOK},
+		{method: http.MethodGet, path: "/api/v1/user/profile", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, nil))
+		if recorder.Code != tt.status {
+			t.Errorf("%s %s: got status %d, want %d", tt.method, tt.path, recorder.Code, tt.status)
+		}
+		if noStore := recorder.Header().Get("Cache-Control") == "no-store"; noStore != tt.noStore {
+			t.Errorf("%s %s: got no-store %t, want %t", tt.method, tt.path, noStore, tt.noStore)
+		}
+	}
+}
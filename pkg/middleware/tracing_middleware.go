@@ -0,0 +1,13 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/pkg/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// Tracing is a middleware that starts a server span for every request,
+// continuing the trace from an incoming W3C traceparent header
+func Tracing() gin.HandlerFunc {
+	return otelgin.Middleware(tracing.ServiceName())
+}
@@ -2,43 +2,36 @@ package config
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/yorukot/go-template/pkg/logger"
+	appConfig "github.com/yorukot/go-template/pkg/config"
 	"github.com/yorukot/go-template/pkg/utils"
-	"github.com/joho/godotenv"
 	"github.com/markbates/goth"
 	"github.com/markbates/goth/providers/github"
 	"github.com/markbates/goth/providers/gitlab"
 	"github.com/markbates/goth/providers/google"
 )
 
-// Init oauth for goth
-func init() {
-	err := godotenv.Load("template.env")
-	if err != nil {
-		logger.Log.Fatal("Error loading .env file")
-	}
-
+// Init oauth for goth, utils.Init must run first so the callback URLs are known
+func Init(cfg appConfig.OAuthConfig) {
 	// Change your url
 	goth.UseProviders(
 		google.New(
-			os.Getenv("GOOGLE_CLIENT_ID"),
-			os.Getenv("GOOGLE_CLIENT_SECRET"),
+			cfg.GoogleClientID,
+			cfg.GoogleClientSecret,
 			fmt.Sprintf("%s/auth/oauth/google/callback", utils.BackendURL),
 			"email",
 			"profile",
 		),
 
 		github.New(
-			os.Getenv("GITHUB_CLIENT_ID"),
-			os.Getenv("GITHUB_CLIENT_SECRET"),
+			cfg.GithubClientID,
+			cfg.GithubClientSecret,
 			fmt.Sprintf("%s/auth/oauth/github/callback", utils.BackendURL),
 		),
 
 		gitlab.New(
-			os.Getenv("GITLAB_CLIENT_ID"),
-			os.Getenv("GITLAB_CLIENT_SECRET"),
+			cfg.GitlabClientID,
+			cfg.GitlabClientSecret,
 			fmt.Sprintf("%s/auth/oauth/gitlab/callback", utils.BackendURL),
 		),
 	)
@@ -6,105 +6,127 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/yorukot/go-template/pkg/config"
 	"github.com/yorukot/go-template/pkg/logger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
 )
 
-var Client *s3.Client
-
-var (
+// Store holds the S3 client and the bucket settings
+type Store struct {
+	Client          *s3.Client
 	StaticBucket    string
 	StaticBucketUrl string
-)
-
-func init() {
-	StaticBucket = os.Getenv("S3_STATIC_BUCKET")
-	StaticBucketUrl = os.Getenv("S3_STATIC_BUCKET_BASEURL")
+	PrivateBucket   string
+}
 
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(os.Getenv("S3_ACCESS_KEY_ID"), os.Getenv("S3_SECRET_KEY"), "")),
-		config.WithRegion("auto"),
+// New creates the S3 client and makes sure the static bucket exists and is
+// publicly readable, and that the private bucket exists
+func New(ctx context.Context, s3Config config.S3Config) (*Store, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx,
+		awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(s3Config.AccessKeyID, s3Config.SecretKey, "")),
+		awsconfig.WithRegion("auto"),
 	)
 	if err != nil {
-		logger.Log.Fatal(err.Error())
+		return nil, fmt.Errorf("failed to load S3 config: %w", err)
 	}
 
-	// Create a custom transport to disable TLS verification
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true, // Disable SSL verification
-		},
-	}
+	// Create a span around every S3 call
+	otelaws.AppendMiddlewares(&cfg.APIOptions)
 
-	// Parse S3_PATH_STYLE environment variable
-	pathStyle := os.Getenv("S3_PATH_STYLE")
-	usePathStyle := false // Default to false
-	if pathStyle == "true" {
-		usePathStyle = true
-	} else if pathStyle == "false" {
-		usePathStyle = false
-	} else {
-		logger.Log.Fatal("S3_PATH_STYLE is not set to a recognized value. Defaulting to false.")
+	// Certificates are verified unless S3_INSECURE_SKIP_VERIFY is set for a
+	// development endpoint with a self-signed certificate
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if s3Config.InsecureSkipVerify {
+		logger.Log.Warn("S3_INSECURE_SKIP_VERIFY is set, the certificate of the S3 endpoint is not verified")
+		transport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: true,
+		}
 	}
 
-	Client = s3.NewFromConfig(cfg, func(o *s3.Options) {
-		o.BaseEndpoint = aws.String(os.Getenv("S3_ENDPOINT"))
+	s := &Store{
+		StaticBucket:    s3Config.StaticBucket,
+		StaticBucketUrl: s3Config.StaticBucketBaseURL,
+		PrivateBucket:   s3Config.PrivateBucket,
+	}
+	s.Client = s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.BaseEndpoint = aws.String(s3Config.Endpoint)
 		o.HTTPClient = &http.Client{Transport: transport}
-		o.UsePathStyle = usePathStyle // Enable path-style URLs for MinIO
+		o.UsePathStyle = s3Config.PathStyle // Enable path-style URLs for MinIO
 	})
 
-	// Check if the bucket exists
-	bucketsName := []string{StaticBucket}
-	for _, bucketName := range bucketsName {
-		_, err = Client.HeadBucket(context.TODO(), &s3.HeadBucketInput{
-			Bucket: &bucketName,
-		})
-		if err != nil {
-			// Check if the error is due to a non-existent bucket
-			var notFoundErr *types.NotFound
-			if errors.As(err, &notFoundErr) {
-				// If the bucket does not exist, create it
-				_, createErr := Client.CreateBucket(context.TODO(), &s3.CreateBucketInput{
-					Bucket: &bucketName,
-				})
-				if createErr != nil {
-					logger.Log.Sugar().Fatalf("Failed to create bucket %s: %v", bucketName, createErr)
-					return
-				}
-				logger.Log.Sugar().Infof("Bucket %s created successfully.", bucketName)
+	// Check if the buckets exist
+	if err := s.ensureBucket(ctx, s.StaticBucket, true); err != nil {
+		return nil, err
+	}
+	if err := s.ensureBucket(ctx, s.PrivateBucket, false); err != nil {
+		return nil, err
+	}
 
-				// Set the bucket policy to make it publicly readable
-				policy := fmt.Sprintf(`{
-					"Version": "2012-10-17",
-					"Statement": [
-						{
-							"Effect": "Allow",
-							"Principal": "*",
-							"Action": "s3:GetObject",
-							"Resource": "arn:aws:s3:::%s/*"
-						}
-					]
-				}`, bucketName)
+	return s, nil
+}
 
-				_, policyErr := Client.PutBucketPolicy(context.TODO(), &s3.PutBucketPolicyInput{
-					Bucket: &bucketName,
-					Policy: &policy,
-				})
-				if policyErr != nil {
-					logger.Log.Sugar().Fatalf("Failed to set public read policy for bucket %s: %v", bucketName, policyErr)
-					return
-				}
-				logger.Log.Sugar().Info("Public read policy set for bucket %s.", bucketName)
-			} else {
-				logger.Log.Sugar().Fatalf("Failed to check bucket %s: %v", bucketName, err)
-				return
+// ensureBucket creates the bucket when it does not exist, with a public read
+// policy when public is set
+func (s *Store) ensureBucket(ctx context.Context, bucketName string, public bool) error {
+	_, err := s.Client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: &bucketName,
+	})
+	if err == nil {
+		return nil
+	}
+
+	// Check if the error is due to a non-existent bucket
+	var notFoundErr *types.NotFound
+	if !errors.As(err, &notFoundErr) {
+		return fmt.Errorf("failed to check bucket %s: %w", bucketName, err)
+	}
+
+	// If the bucket does not exist, create it
+	_, err = s.Client.CreateBucket(ctx, &s3.CreateBucketInput{
+		Bucket: &bucketName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create bucket %s: %w", bucketName, err)
+	}
+	logger.Log.Sugar().Infof("Bucket %s created successfully.", bucketName)
+	if !public {
+		return nil
+	}
+
+	// Set the bucket policy to make it publicly readable
+	policy := fmt.Sprintf(`{
+		"Version": "2012-10-17",
+		"Statement": [
+			{
+				"Effect": "Allow",
+				"Principal": "*",
+				"Action": "s3:GetObject",
+				"Resource": "arn:aws:s3:::%s/*"
 			}
-		}
+		]
+	}`, bucketName)
+
+	_, err = s.Client.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+		Bucket: &bucketName,
+		Policy: &policy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set public read policy for bucket %s: %w", bucketName, err)
 	}
+	logger.Log.Sugar().Infof("Public read policy set for bucket %s.", bucketName)
+	return nil
+}
+
+// Ping checks that the static bucket is reachable
+func (s *Store) Ping(ctx context.Context) error {
+	_, err := s.Client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: &s.StaticBucket,
+	})
+	return err
 }
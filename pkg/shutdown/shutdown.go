@@ -0,0 +1,70 @@
+package shutdown
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yorukot/go-template/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Phase orders the shutdown hooks, lower phases run first
+type Phase int
+
+// Shutdown phases, run after the HTTP server has stopped accepting requests
+const (
+	PhaseWorkers   Phase = iota // Background workers and auxiliary listeners
+	PhaseCache                  // Redis clients
+	PhaseDatabase               // Database connection pool
+	PhaseTelemetry              // Trace exporters, flushed last so the shutdown itself is traced
+)
+
+// Hook releases a resource, it must return once ctx is done
+type Hook func(ctx context.Context) error
+
+type hook struct {
+	phase Phase
+	name  string
+	fn    Hook
+	order int
+}
+
+var (
+	mu    sync.Mutex
+	hooks []hook
+)
+
+// Register adds a hook to run during shutdown. Hooks in the same phase run in registration order.
+func Register(phase Phase, name string, fn Hook) {
+	mu.Lock()
+	defer mu.Unlock()
+	hooks = append(hooks, hook{phase: phase, name: name, fn: fn, order: len(hooks)})
+}
+
+// Run executes every registered hook phase by phase. Errors are logged and
+// don't stop the remaining hooks from running.
+func Run(ctx context.Context) {
+	mu.Lock()
+	ordered := make([]hook, len(hooks))
+	copy(ordered, hooks)
+	hooks = nil
+	mu.Unlock()
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].phase != ordered[j].phase {
+			return ordered[i].phase < ordered[j].phase
+		}
+		return ordered[i].order < ordered[j].order
+	})
+
+	for _, h := range ordered {
+		start := time.Now()
+		if err := h.fn(ctx); err != nil {
+			logger.Log.Error("Shutdown hook failed", zap.String("hook", h.name), zap.Error(err))
+			continue
+		}
+		logger.Log.Info("Shutdown hook completed", zap.String("hook", h.name), zap.Duration("latency", time.Since(start)))
+	}
+}
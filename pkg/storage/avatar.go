@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// AvatarSizes are the square sizes in pixels of the uploaded avatars, the
+// avatar URL of the user points to the first
+var AvatarSizes = []int{256, 128, 64}
+
+// avatarPrefix is the folder of the uploaded avatars in the public storage
+const avatarPrefix = "avatars/"
+
+// AvatarKey returns the key of one size of an avatar. The sizes of an upload
+// share a folder named after the hash of their content, so a new upload gets
+// new URLs that caches and CDNs have never seen.
+func AvatarKey(userID uint64, hash string, size int, ext string) string {
+	return fmt.Sprintf("%s%d/%s/%d.%s", avatarPrefix, userID, hash, size, ext)
+}
+
+// DeleteAvatar deletes every size of an avatar uploaded to the public storage.
+// Other public objects are deleted alone, and avatars hosted elsewhere, like
+// those of OAuth providers, are left untouched.
+func DeleteAvatar(ctx context.Context, public *Public, avatarURL string) error {
+	key, ok := public.Key(avatarURL)
+	if !ok {
+		return nil
+	}
+	if strings.HasPrefix(key, avatarPrefix) {
+		return DeletePrefix(ctx, public, path.Dir(key)+"/")
+	}
+	return public.Delete(ctx, key)
+}
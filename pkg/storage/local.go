@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tempPrefix starts the names of the files being written by Put
+const tempPrefix = ".upload-"
+
+// Local stores objects as files under a directory. Its signed URLs point to
+// the download route of the app, which checks them with Verify and
+// VerifyUpload.
+type Local struct {
+	signer
+	dir string
+}
+
+// NewLocal creates a Storage that keeps the objects under dir and signs the
+// download URLs under baseURL with a key derived from secret, the previous
+// secrets still verify the URLs they signed
+func NewLocal(dir string, baseURL string, secret string, previous ...string) *Local {
+	return &Local{signer: newSigner(baseURL, secret, previous...), dir: dir}
+}
+
+// Dir returns the directory of the objects, for the static route of a public storage
+func (l *Local) Dir() string {
+	return l.dir
+}
+
+// Put writes the object to a temporary file and renames it into place, so a
+// download never sees a partial file
+func (l *Local) Put(_ context.Context, key string, body io.Reader, _ int64, _ string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(name), tempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name()) // Fails harmlessly once renamed
+
+	if _, err := io.Copy(file, body); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), name)
+}
+
+// Get opens the file of the object
+func (l *Local) Get(_ context.Context, key string) (io.ReadCloser, Object, error) {
+	name, err := l.path(key)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	file, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, Object{}, ErrNotFound
+	} else if err != nil {
+		return nil, Object{}, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, Object{}, err
+	}
+	if !info.Mode().IsRegular() {
+		file.Close()
+		return nil, Object{}, ErrNotFound
+	}
+	return file, localObject(key, info), nil
+}
+
+// Delete removes the file of the object
+func (l *Local) Delete(_ context.Context, key string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Stat describes the file of the object
+func (l *Local) Stat(_ context.Context, key string) (Object, error) {
+	name, err := l.path(key)
+	if err != nil {
+		return Object{}, err
+	}
+	info, err := os.Stat(name)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && !info.Mode().IsRegular()) {
+		return Object{}, ErrNotFound
+	} else if err != nil {
+		return Object{}, err
+	}
+	return localObject(key, info), nil
+}
+
+// List walks the directory for the files whose key starts with prefix
+func (l *Local) List(_ context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(l.dir, func(name string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil // Nothing stored yet
+		} else if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), tempPrefix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.dir, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, localObject(key, info))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// SignedURL returns the download route URL with the expiry and its signature
+func (l *Local) SignedURL(_ context.Context, key string, ttl time.Duration) (string, error) {
+	return l.signedURL(key, ttl)
+}
+
+// SignedUploadURL returns the upload route URL with the expiry and the
+// signature of the content type and the size
+func (l *Local) SignedUploadURL(_ context.Context, key string, contentType string, size int64, ttl time.Duration) (string, error) {
+	return l.signedUploadURL(key, contentType, size, ttl)
+}
+
+// path returns the file of the key
+func (l *Local) path(key string) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+// localObject describes a file, its content type follows its extension
+func localObject(key string, info fs.FileInfo) Object {
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return Object{Key: key, Size: info.Size(), ContentType: contentType, LastModified: info.ModTime()}
+}
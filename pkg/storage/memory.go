@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory stores objects in memory, it is meant for tests and throwaway
+// development servers. Its signed URLs point to the download route of the
+// app like those of Local.
+type Memory struct {
+	signer
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+// memoryObject is a stored object with its content
+type memoryObject struct {
+	Object
+	data []byte
+}
+
+// NewMemory creates an empty Storage that signs the download URLs under
+// baseURL with a key derived from secret, the previous secrets still verify
+// the URLs they signed
+func NewMemory(baseURL string, secret string, previous ...string) *Memory {
+	return &Memory{signer: newSigner(baseURL, secret, previous...), objects: map[string]memoryObject{}}
+}
+
+// Put reads the object into memory
+func (m *Memory) Put(_ context.Context, key string, body io.Reader, _ int64, contentType string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.objects[key] = memoryObject{
+		Object: Object{Key: key, Size: int64(len(data)), ContentType: contentType, LastModified: time.Now()},
+		data:   data,
+	}
+	return nil
+}
+
+// Get returns a reader of the object
+func (m *Memory) Get(_ context.Context, key string) (io.ReadCloser, Object, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	object, ok := m.objects[key]
+	if !ok {
+		return nil, Object{}, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(object.data)), object.Object, nil
+}
+
+// Delete removes the object
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.objects, key)
+	return nil
+}
+
+// Stat describes the object
+func (m *Memory) Stat(_ context.Context, key string) (Object, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	object, ok := m.objects[key]
+	if !ok {
+		return Object{}, ErrNotFound
+	}
+	return object.Object, nil
+}
+
+// List describes the objects whose key starts with prefix
+func (m *Memory) List(_ context.Context, prefix string) ([]Object, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var objects []Object
+	for key, object := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, object.Object)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// SignedURL returns the download route URL with the expiry and its signature
+func (m *Memory) SignedURL(_ context.Context, key string, ttl time.Duration) (string, error) {
+	return m.signedURL(key, ttl)
+}
+
+// SignedUploadURL returns the upload route URL with the expiry and the
+// signature of the content type and the size
+func (m *Memory) SignedUploadURL(_ context.Context, key string, contentType string, size int64, ttl time.Duration) (string, error) {
+	return m.signedUploadURL(key, contentType, size, ttl)
+}
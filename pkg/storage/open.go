@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/yorukot/go-template/pkg/config"
+	store "github.com/yorukot/go-template/pkg/s3"
+)
+
+// Routes of the app that serve the local and memory backends, under the API
+// base URL. The download route also takes the signed uploads with PUT.
+const (
+	StaticRoute   = "/static"
+	DownloadRoute = "/files"
+)
+
+// Open creates the public and private storages of STORAGE_BACKEND. The s3
+// backend uses the buckets of s3Store, which is nil for the other backends.
+// The local and memory backends are served by the app under apiURL, and the
+// local one keeps the public and private files in separate directories so
+// the static route never exposes a private file. The download route only
+// serves the private storage, the signed URLs of the public one point to the
+// static route, which ignores the signature.
+func Open(cfg *config.Config, s3Store *store.Store, apiURL string) (*Public, Storage, error) {
+	switch cfg.Storage.Backend {
+	case "s3":
+		if s3Store == nil {
+			return nil, nil, fmt.Errorf("STORAGE_BACKEND=s3 requires S3_ENABLED=true")
+		}
+		baseURL := cfg.S3.StaticBucketBaseURL
+		if baseURL == "" {
+			baseURL = strings.TrimSuffix(cfg.S3.Endpoint, "/") + "/" + cfg.S3.StaticBucket
+		}
+		public := &Public{Storage: NewS3(s3Store, cfg.S3.StaticBucket), BaseURL: baseURL}
+		return public, NewS3(s3Store, cfg.S3.PrivateBucket), nil
+	case "local":
+		secret, previous := signingKeys(cfg)
+		public := &Public{
+			Storage: NewLocal(filepath.Join(cfg.Storage.LocalDir, "public"), apiURL+StaticRoute, secret, previous...),
+			BaseURL: apiURL + StaticRoute,
+		}
+		return public, NewLocal(filepath.Join(cfg.Storage.LocalDir, "private"), apiURL+DownloadRoute, secret, previous...), nil
+	case "memory":
+		secret, previous := signingKeys(cfg)
+		public := &Public{Storage: NewMemory(apiURL+StaticRoute, secret, previous...), BaseURL: apiURL + StaticRoute}
+		return public, NewMemory(apiURL+DownloadRoute, secret, previous...), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown STORAGE_BACKEND %q", cfg.Storage.Backend)
+	}
+}
+
+// signingKeys returns the secret of the signed URLs and the previous secrets
+// that still verify them. Without STORAGE_SIGNING_KEY the JWT key is used, and
+// the keys moved to JWT_PREVIOUS_SECRET_KEYS by "keys rotate" keep the links
+// signed before the rotation valid.
+func signingKeys(cfg *config.Config) (string, []string) {
+	if cfg.Storage.SigningKey != "" {
+		return cfg.Storage.SigningKey, nil
+	}
+	return cfg.Cookie.JWTSecretKey, cfg.Cookie.JWTPreviousSecretKeys
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// signer signs the URLs of the storages served by the download route of the
+// app, the signature covers the key and the expiry. Upload URLs are signed
+// with another key and also cover the content type and the size, so neither
+// kind of link can stand in for the other.
+type signer struct {
+	baseURL string       // URL of the download route, the key is appended to it
+	keys    []signerKeys // the first signs, every one verifies
+}
+
+// signerKeys are the keys derived from one secret
+type signerKeys struct {
+	secret       []byte
+	uploadSecret []byte
+}
+
+// newSigner derives the signing keys from secret, so the secret can be shared
+// with other uses. The previous secrets only verify the links signed before a
+// key rotation.
+func newSigner(baseURL string, secret string, previous ...string) signer {
+	s := signer{baseURL: baseURL}
+	for _, secret := range append([]string{secret}, previous...) {
+		s.keys = append(s.keys, signerKeys{
+			secret:       deriveKey(secret, "storage signed urls"),
+			uploadSecret: deriveKey(secret, "storage signed uploads"),
+		})
+	}
+	return s
+}
+
+// signedURL returns the download route URL of the key with the expiry and its signature
+func (s signer) signedURL(key string, ttl time.Duration) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	return s.url(key, expires, s.keys[0].sign(key, expires)), nil
+}
+
+// signedUploadURL returns the upload route URL of the key with the expiry and
+// the signature of the content type and the size
+func (s signer) signedUploadURL(key string, contentType string, size int64, ttl time.Duration) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	return s.url(key, expires, s.keys[0].signUpload(key, contentType, size, expires)), nil
+}
+
+// Verify checks the expiry and the signature of a signed URL
+func (s signer) Verify(key string, expires string, signature string) error {
+	return s.verify(expires, signature, func(k signerKeys) string { return k.sign(key, expires) })
+}
+
+// VerifyUpload checks the expiry and the signature of a signed upload URL
+func (s signer) VerifyUpload(key string, contentType string, size int64, expires string, signature string) error {
+	return s.verify(expires, signature, func(k signerKeys) string { return k.signUpload(key, contentType, size, expires) })
+}
+
+// url returns the route URL of the key with the expiry and the signature
+func (s signer) url(key string, expires string, signature string) string {
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", signature)
+	return s.baseURL + "/" + (&url.URL{Path: key}).EscapedPath() + "?" + query.Encode()
+}
+
+// sign returns the hex HMAC-SHA256 of the key and the expiry
+func (k signerKeys) sign(key string, expires string) string {
+	return mac(k.secret, key+"\n"+expires)
+}
+
+// signUpload returns the hex HMAC-SHA256 of the key, the expiry, the content type and the size
+func (k signerKeys) signUpload(key string, contentType string, size int64, expires string) string {
+	return mac(k.uploadSecret, key+"\n"+expires+"\n"+contentType+"\n"+strconv.FormatInt(size, 10))
+}
+
+// verify checks that the link has not expired and that the signature is the
+// one computed by expected with any of the keys
+func (s signer) verify(expires string, signature string, expected func(signerKeys) string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() >= expiresAt {
+		return ErrInvalidSignature
+	}
+	for _, keys := range s.keys {
+		if hmac.Equal([]byte(signature), []byte(expected(keys))) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// deriveKey derives the key of one use from secret
+func deriveKey(secret string, label string) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(label))
+	return h.Sum(nil)
+}
+
+// mac returns the hex HMAC-SHA256 of the message
+func mac(key []byte, message string) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(message))
+	return hex.EncodeToString(h.Sum(nil))
+}
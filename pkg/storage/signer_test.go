@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseSignedURL returns the key, the expiry and the signature of a signed URL
+func parseSignedURL(t *testing.T, baseURL string, signed string) (string, string, string) {
+	t.Helper()
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := strings.TrimPrefix(u.Path, base.Path+"/")
+	return key, u.Query().Get("expires"), u.Query().Get("signature")
+}
+
+func TestSignerVerify(t *testing.T) {
+	const baseURL = "http://localhost:8080/api/v1/files"
+	s := NewMemory(baseURL, "secret")
+	signed, err := s.SignedURL(context.Background(), "exports/1/2.zip", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, expires, signature := parseSignedURL(t, baseURL, signed)
+	past := strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10)
+
+	tests := []struct {
+		name      string
+		verifier  Verifier
+		key       string
+		expires   string
+		signature string
+		valid     bool
+	}{
+		{name: "valid", verifier: s, key: key, expires: expires, signature: signature, valid: true},
+		{name: "other key", verifier: s, key: "exports/1/3.zip", expires: expires, signature: signature},
+		{name: "extended expiry", verifier: s, key: key, expires: expires + "0", signature: signature},
+		{name: "tampered signature", verifier: s, key: key, expires: expires, signature: strings.Repeat("0", len(signature))},
+		{name: "empty signature", verifier: s, key: key, expires: expires},
+		{name: "invalid expiry", verifier: s, key: key, expires: "soon", signature: signature},
+		{name: "expired", verifier: s, key: key, expires: past, signature: s.keys[0].sign(key, past)},
+		{name: "other secret", verifier: NewMemory(baseURL, "another secret"), key: key, expires: expires, signature: signature},
+		{name: "previous secret", verifier: NewMemory(baseURL, "new secret", "secret"), key: key, expires: expires, signature: signature, valid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.verifier.Verify(tt.key, tt.expires, tt.signature)
+			if tt.valid && err != nil {
+				t.Errorf("got %v, want a valid link", err)
+			} else if !tt.valid && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("got %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestSignerVerifyUpload(t *testing.T) {
+	const baseURL = "http://localhost:8080/api/v1/files"
+	s := NewMemory(baseURL, "secret")
+	signed, err := s.SignedUploadURL(context.Background(), "uploads/1/2.png", "image/png", 1024, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, expires, signature := parseSignedURL(t, baseURL, signed)
+	past := strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10)
+
+	tests := []struct {
+		name        string
+		key         string
+		contentType string
+		size        int64
+		expires     string
+		signature   string
+		valid       bool
+	}{
+		{name: "valid", key: key, contentType: "image/png", size: 1024, expires: expires, signature: signature, valid: true},
+		{name: "other content type", key: key, contentType: "text/html", size: 1024, expires: expires, signature: signature},
+		{name: "other size", key: key, contentType: "image/png", size: 1025, expires: expires, signature: signature},
+		{name: "other key", key: "uploads/1/3.png", contentType: "image/png", size: 1024, expires: expires, signature: signature},
+		{name: "tampered signature", key: key, contentType: "image/png", size: 1024, expires: expires, signature: "0" + signature[1:]},
+		{name: "expired", key: key, contentType: "image/png", size: 1024, expires: past, signature: s.keys[0].signUpload(key, "image/png", 1024, past)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.VerifyUpload(tt.key, tt.contentType, tt.size, tt.expires, tt.signature)
+			if tt.valid && err != nil {
+				t.Errorf("got %v, want a valid link", err)
+			} else if !tt.valid && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("got %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+
+	// Neither kind of link stands in for the other
+	download, err := s.SignedURL(context.Background(), key, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, downloadExpires, downloadSignature := parseSignedURL(t, baseURL, download)
+	if err := s.VerifyUpload(key, "image/png", 1024, downloadExpires, downloadSignature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("got %v for a download link used to upload, want ErrInvalidSignature", err)
+	}
+	if err := s.Verify(key, expires, signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("got %v for an upload link used to download, want ErrInvalidSignature", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Package storage keeps the files of the app behind one interface, backed by
+// S3, the local disk or memory as STORAGE_BACKEND selects. The app uses a
+// public storage, whose files have stable URLs, and a private one, whose
+// files are only handed out through signed URLs that expire.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Errors returned by every implementation
+var (
+	// ErrInvalidKey is returned for keys that are empty, absolute or leave their directory
+	ErrInvalidKey = errors.New("invalid object key")
+	// ErrNotFound is returned by Get and Stat for missing objects
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidSignature is returned by Verify for links that are forged or expired
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+)
+
+// Object describes a stored object
+type Object struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// Storage stores objects by key, keys are slash separated paths
+type Storage interface {
+	// Put stores the object, replacing any object with the same key
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the object, the caller closes it. It returns ErrNotFound
+	// when the object does not exist.
+	Get(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	// Delete removes the object, a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// Stat describes the object, it returns ErrNotFound when the object does not exist
+	Stat(ctx context.Context, key string) (Object, error)
+	// List describes every object whose key starts with prefix, sorted by key
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// SignedURL returns a URL that downloads the object until ttl has passed
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+	// SignedUploadURL returns a URL that stores the object with a PUT request
+	// until ttl has passed. The request must send the Content-Type and the
+	// Content-Length headers with the given values.
+	SignedUploadURL(ctx context.Context, key string, contentType string, size int64, ttl time.Duration) (string, error)
+}
+
+// Verifier is implemented by the storages whose signed URLs point to the
+// download and upload routes of the app instead of the storage itself
+type Verifier interface {
+	// Verify checks the expiry and the signature of a signed URL, it returns
+	// ErrInvalidSignature when the link can't be used
+	Verify(key string, expires string, signature string) error
+	// VerifyUpload checks a signed upload URL against the content type and
+	// the size of the request, it returns ErrInvalidSignature when they differ
+	// from the signed ones or the link can't be used
+	VerifyUpload(key string, contentType string, size int64, expires string, signature string) error
+}
+
+// Public is a storage whose objects are readable by anyone at BaseURL/key
+type Public struct {
+	Storage
+	BaseURL string
+}
+
+// URL returns the public URL of an object
+func (p *Public) URL(key string) string {
+	return strings.TrimSuffix(p.BaseURL, "/") + "/" + key
+}
+
+// Key returns the object key of a URL returned by URL, false when the URL
+// points somewhere else, like the avatar of an OAuth provider
+func (p *Public) Key(objectURL string) (string, bool) {
+	prefix := strings.TrimSuffix(p.BaseURL, "/") + "/"
+	if p.BaseURL == "" || !strings.HasPrefix(objectURL, prefix) {
+		return "", false
+	}
+	key := strings.TrimPrefix(objectURL, prefix)
+	return key, validKey(key)
+}
+
+// DeletePrefix deletes every object whose key starts with prefix
+func DeletePrefix(ctx context.Context, s Storage, prefix string) error {
+	objects, err := s.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if err := s.Delete(ctx, object.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validKey reports whether the key is a clean relative path
+func validKey(key string) bool {
+	return key != "" && !strings.HasPrefix(key, "/") && path.Clean(key) == key && key != "." &&
+		key != ".." && !strings.HasPrefix(key, "../")
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{key: "avatars/1/256.png", valid: true},
+		{key: "file.txt", valid: true},
+		{key: "a..b/c", valid: true},
+		{key: ""},
+		{key: "."},
+		{key: ".."},
+		{key: "../secret"},
+		{key: "../../etc/passwd"},
+		{key: "avatars/../../secret"},
+		{key: "avatars/./1.png"},
+		{key: "avatars//1.png"},
+		{key: "avatars/"},
+		{key: "/etc/passwd"},
+		{key: "/avatars/1.png"},
+	}
+
+	for _, tt := range tests {
+		if got := validKey(tt.key); got != tt.valid {
+			t.Errorf("validKey(%q) = %t, want %t", tt.key, got, tt.valid)
+		}
+	}
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory("http://localhost:8080/api/v1/files", "secret")
+
+	for _, key := range []string{"uploads/1/a.txt", "uploads/1/b.txt", "uploads/2/a.txt"} {
+		if err := m.Put(ctx, key, strings.NewReader("content of "+key), -1, "text/plain"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	body, object, err := m.Get(ctx, "uploads/1/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "content of uploads/1/a.txt" || object.Size != int64(len(data)) || object.ContentType != "text/plain" {
+		t.Errorf("got %q as %+v", data, object)
+	}
+
+	if err := DeletePrefix(ctx, m, "uploads/1/"); err != nil {
+		t.Fatal(err)
+	}
+	objects, err := m.List(ctx, "uploads/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, object := range objects {
+		keys = append(keys, object.Key)
+	}
+	if !reflect.DeepEqual(keys, []string{"uploads/2/a.txt"}) {
+		t.Errorf("got keys %q after deleting uploads/1/, want [uploads/2/a.txt]", keys)
+	}
+
+	if _, err := m.Stat(ctx, "uploads/1/a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v for a deleted object, want ErrNotFound", err)
+	}
+	if err := m.Delete(ctx, "uploads/1/a.txt"); err != nil {
+		t.Errorf("got %v deleting a missing object, want nil", err)
+	}
+
+	for _, key := range []string{"../secret", "/etc/passwd", "uploads/../../secret"} {
+		if err := m.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("got %v putting %q, want ErrInvalidKey", err, key)
+		}
+		if _, err := m.SignedURL(ctx, key, time.Hour); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("got %v signing %q, want ErrInvalidKey", err, key)
+		}
+	}
+}
+
+func TestPublicKey(t *testing.T) {
+	p := &Public{Storage: NewMemory("http://localhost:8080/api/v1/static", "secret"), BaseURL: "http://localhost:8080/api/v1/static/"}
+
+	tests := []struct {
+		url string
+		key string
+		ok  bool
+	}{
+		{url: p.URL("avatars/1/256.png"), key: "avatars/1/256.png", ok: true},
+		{url: "https://avatars.githubusercontent.com/u/1"},
+		{url: "http://localhost:8080/api/v1/static/../files/exports/1/2.zip"},
+		{url: "http://localhost:8080/api/v1/static//etc/passwd"},
+	}
+
+	for _, tt := range tests {
+		key, ok := p.Key(tt.url)
+		if ok != tt.ok || (ok && key != tt.key) {
+			t.Errorf("Key(%q) = %q, %t, want %q, %t", tt.url, key, ok, tt.key, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "tracing:span"
+
+// RegisterDatabase instruments GORM callbacks with client spans. Spans are
+// children of the span in the statement context, so queries must use
+// db.WithContext(ctx) to be linked to the request trace.
+func RegisterDatabase(db *gorm.DB, system string) error {
+	callback := db.Callback()
+	registrations := []error{
+		callback.Create().Before("gorm:create").Register("tracing:before_create", beforeQuery("create", system)),
+		callback.Create().After("gorm:create").Register("tracing:after_create", afterQuery),
+		callback.Query().Before("gorm:query").Register("tracing:before_query", beforeQuery("query", system)),
+		callback.Query().After("gorm:query").Register("tracing:after_query", afterQuery),
+		callback.Update().Before("gorm:update").Register("tracing:before_update", beforeQuery("update", system)),
+		callback.Update().After("gorm:update").Register("tracing:after_update", afterQuery),
+		callback.Delete().Before("gorm:delete").Register("tracing:before_delete", beforeQuery("delete", system)),
+		callback.Delete().After("gorm:delete").Register("tracing:after_delete", afterQuery),
+		callback.Row().Before("gorm:row").Register("tracing:before_row", beforeQuery("row", system)),
+		callback.Row().After("gorm:row").Register("tracing:after_row", afterQuery),
+		callback.Raw().Before("gorm:raw").Register("tracing:before_raw", beforeQuery("raw", system)),
+		callback.Raw().After("gorm:raw").Register("tracing:after_raw", afterQuery),
+	}
+	for _, err := range registrations {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// beforeQuery starts a span and stores it on the statement
+func beforeQuery(operation string, system string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := Tracer().Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemKey.String(system),
+				semconv.DBOperationName(operation),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+// afterQuery records the statement and ends the span
+func afterQuery(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		semconv.DBCollectionName(db.Statement.Table),
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+	if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
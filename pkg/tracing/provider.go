@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yorukot/go-template/pkg/config"
+	"github.com/yorukot/go-template/pkg/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name used for spans created by this application
+const TracerName = "github.com/yorukot/go-template"
+
+// Supported trace exporters
+const (
+	ExporterNone   = "none"   // Tracing disabled
+	ExporterOTLP   = "otlp"   // OTLP over HTTP, see OTEL_EXPORTER_OTLP_* variables
+	ExporterStdout = "stdout" // Pretty printed spans on stdout for local use
+)
+
+// Init configures the global tracer provider and W3C propagators from the
+// tracing section of the config. OTEL_EXPORTER_OTLP_ENDPOINT and friends are
+// read by the OTLP exporter itself.
+//
+// The returned function flushes and stops the provider, it must be called on shutdown.
+func Init(ctx context.Context) (func(context.Context) error, error) {
+	// Honour incoming traceparent and baggage headers even when we don't export
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	cfg := config.Get()
+	exporterType := strings.ToLower(cfg.Tracing.Exporter)
+	if exporterType == ExporterNone {
+		logger.Log.Info("OTEL_TRACES_EXPORTER not set, tracing disabled")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch exporterType {
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter: %s", exporterType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", exporterType, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(ServiceName()),
+		semconv.ServiceVersion(cfg.App.Version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Tracing.SamplerRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	logger.Log.Sugar().Infof("Tracing enabled with %s exporter", exporterType)
+	return provider.Shutdown, nil
+}
+
+// ServiceName returns the service name reported with every span
+func ServiceName() string {
+	return config.Get().Tracing.ServiceName
+}
+
+// Tracer returns the application tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
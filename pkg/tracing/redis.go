@@ -0,0 +1,11 @@
+package tracing
+
+import (
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
+)
+
+// RegisterRedis instruments the Redis client with a span for every command
+func RegisterRedis(client *redis.Client) error {
+	return redisotel.InstrumentTracing(client)
+}
@@ -0,0 +1,11 @@
+package utils
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// SetCookie sets a cookie using the configured domain, path, SameSite policy and secure flag
+func SetCookie(c *gin.Context, name string, value string, maxAge int, httpOnly bool) {
+	c.SetSameSite(CookieSameSite)
+	c.SetCookie(name, value, maxAge, CookiePath, CookieDomain, secret, httpOnly)
+}
@@ -5,12 +5,21 @@ const (
 	ErrAuthenticationKeyNotFound = "authentication_key_not_found"
 	ErrUnauthorized              = "unauthorized"
 	ErrTokenExpired              = "token_expired"
+	ErrCSRFTokenInvalid          = "csrf_token_invalid"
+	ErrPermissionDenied          = "permission_denied"
+	ErrAccountDisabled           = "account_disabled"
 )
 
 // Request errors
 const (
-	ErrBadRequest     = "bad_request"
-	ErrUserIDNotFound = "user_id_not_found"
+	ErrBadRequest       = "bad_request"
+	ErrUserIDNotFound   = "user_id_not_found"
+	ErrOriginNotAllowed = "origin_not_allowed"
+	ErrTooManyRequests  = "too_many_requests"
+	ErrFileTooLarge     = "file_too_large"
+	ErrInvalidImage     = "invalid_image"
+	ErrUploadIncomplete = "upload_incomplete"
+	ErrUploadMismatch   = "upload_mismatch"
 )
 
 // User-related errors
@@ -30,6 +39,8 @@ const (
 
 // Internal errors
 const (
+	ErrInternal        = "internal_error"
+	ErrServiceNotReady = "service_not_ready"
 	ErrHashData        = "hash_data_failed"
 	ErrParseFile       = "template_parse_failed"
 	ErrParse           = "data_parse_failed"
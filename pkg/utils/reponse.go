@@ -1,7 +1,11 @@
 package utils
 
 import (
+	"fmt"
+
 	"github.com/gin-gonic/gin"
+	"github.com/yorukot/go-template/pkg/logger"
+	"go.uber.org/zap"
 )
 
 // ServerErrorResponse is a helper function to handle errors and send responses
@@ -31,7 +35,10 @@ func FullyResponse(c *gin.Context, statusCode int, message string, errorCode int
 	case nil:
 		errorCodePtr = nil
 	default:
-		panic("invalid errorCode type")
+		// Never panic while building an error response, fall back to a generic code
+		logger.Log.Error("Invalid errorCode type", zap.String("type", fmt.Sprintf("%T", v)))
+		internal := ErrInternal
+		errorCodePtr = &internal
 	}
 
 	if errorCodePtr != nil {
@@ -2,17 +2,18 @@ package utils
 
 import (
 	"fmt"
-	"os"
 
+	"github.com/yorukot/go-template/pkg/config"
 	"gopkg.in/gomail.v2"
 )
 
 func SendEmail(to string, subject string, body string) error {
-	from := os.Getenv("SMTP_FROM")
-	username := os.Getenv("SMTP_USERNAME")
-	key := os.Getenv("SMTP_KEY")
-	smtpHost := os.Getenv("SMTP_HOST")
-	smtpPort := Atoi(os.Getenv("SMTP_PORT"))
+	cfg := config.Get().SMTP
+	from := cfg.From
+	username := cfg.Username
+	key := cfg.Key
+	smtpHost := cfg.Host
+	smtpPort := cfg.Port
 
 	m := gomail.NewMessage()
 	m.SetHeader("From", from)
@@ -1,27 +1,35 @@
 package utils
 
 import (
-	"fmt"
-	"os"
-	"strings"
+	"context"
+	"errors"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yorukot/go-template/app/models"
-	"github.com/yorukot/go-template/app/queries"
+	"github.com/yorukot/go-template/app/repository"
 	"github.com/yorukot/go-template/pkg/encryption"
-	"gorm.io/gorm"
 )
 
+// secret marks cookies as Secure, it is set by Init when BASE_URL is https
 var secret bool = false
 
 // Generate new user access_token and refresh_token
-func GenerateUserSession(c *gin.Context, userID uint64) error {
-	var err error
-	secretKey, err := encryption.RandStringRunes(1024, true)
+func GenerateUserSession(c *gin.Context, sessions repository.SessionRepository, userID uint64) error {
+	session, err := CreateUserSession(c.Request.Context(), sessions, userID)
 	if err != nil {
 		return err
 	}
+	return SetSessionCookies(c, session)
+}
+
+// CreateUserSession stores a new refresh token session for the user. It sets
+// no cookie, so it can run in a transaction whose commit may still fail.
+func CreateUserSession(ctx context.Context, sessions repository.SessionRepository, userID uint64) (models.Session, error) {
+	secretKey, err := encryption.RandStringRunes(1024, true)
+	if err != nil {
+		return models.Session{}, err
+	}
 	session := models.Session{
 		SessionID: encryption.GenerateID(),
 		SecretKey: secretKey,
@@ -30,50 +38,47 @@ func GenerateUserSession(c *gin.Context, userID uint64) error {
 		CreatedAt: time.Now(),
 	}
 
-	// Check if a session with the same secretKey already exists
+	// Create the new session, regenerating the secretKey if it is already used
 	for {
-		_, result := queries.GetSessionQueueBySecretKey(session.SecretKey)
-		if result.Error == gorm.ErrRecordNotFound {
+		err = sessions.Create(ctx, &session)
+		if err == nil {
 			break
-		} else if result.Error != nil {
-			// Handle any other errors
-			fmt.Println(result.Error.Error())
-			return result.Error
+		} else if !errors.Is(err, repository.ErrConflict) {
+			return models.Session{}, err
 		}
 
 		secretKey, err = encryption.RandStringRunes(1024, true)
 		if err != nil {
-			return err
+			return models.Session{}, err
 		}
 		session.SecretKey = secretKey
 	}
 
-	// Create the new session in the database
-	result := queries.CreateSessionQueue(session)
-	if result.Error != nil {
-		return result.Error
-	}
+	return session, nil
+}
+
+// SetSessionCookies sets the refresh token cookie of the session and a new
+// access token cookie
+func SetSessionCookies(c *gin.Context, session models.Session) error {
+	SetCookie(c, "refresh_token", session.SecretKey, CookieRefreshTokenExpires*24*60*60, true)
+
+	return GenerateAccessToken(c, session.UserID)
+}
 
-	// Generate the access token
+// Generate new user access_token and set it as a cookie
+func GenerateAccessToken(c *gin.Context, userID uint64) error {
 	accessTokenExpiresAt := time.Now().Add(time.Minute * time.Duration(CookieAccessTokenExpires))
 	accessToken, err := encryption.GenerateNewJwtToken(userID, []string{}, accessTokenExpiresAt)
 	if err != nil {
 		return err
 	}
 
-	// Set the cookies
-	c.SetCookie("refresh_token", session.SecretKey, CookieRefreshTokenExpires*24*60*60, "", "", secret, true)
-	c.SetCookie("access_token", accessToken, CookieAccessTokenExpires*60, "/", "", secret, false)
+	SetCookie(c, "access_token", accessToken, CookieAccessTokenExpires*60, false)
 
 	return nil
 }
 
-func init() {
-	baseURL := os.Getenv("BASE_URL")
-
-	if strings.HasPrefix(baseURL, "https://") {
-		secret = true
-	} else {
-		secret = false
-	}
-}
\ No newline at end of file
+// IsHTTPS reports whether BASE_URL is served over https
+func IsHTTPS() bool {
+	return secret
+}
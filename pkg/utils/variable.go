@@ -3,9 +3,13 @@ package utils
 import (
 	"bytes"
 	"fmt"
-	"os"
+	"net/http"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
+
+	"github.com/yorukot/go-template/pkg/config"
 )
 
 var (
@@ -17,16 +21,54 @@ var (
 	FrontendURl              string
 	GiteaORGName             string
 	GiteaCommitEmail         string
+	CookieDomain             string
+	CookiePath               string
+	CookieSameSite           http.SameSite
+	// Deleted accounts can be restored by logging in until the grace period ends
+	AccountDeletionGracePeriod time.Duration
+	// Signups are refused for the emails of purged accounts
+	ReserveDeletedEmails bool
+	// A user can request one data export per interval
+	UserExportInterval time.Duration
+	// Avatar uploads larger than these are rejected
+	AvatarMaxSize      int64
+	AvatarMaxDimension int
+	// Uploads through signed URLs larger than this are refused, the URLs expire after the TTL
+	UploadMaxSize int64
+	UploadURLTTL  time.Duration
 )
 
-// Init some usefil variables
-func init() {
-	GiteaORGName = os.Getenv("GITEA_ORG_NAME")
-	CookieRefreshTokenExpires = Atoi(os.Getenv("COOKIE_REFRESH_TOKEN_EXPIRES"))
-	CookieAccessTokenExpires = Atoi(os.Getenv("COOKIE_ACCESS_TOKEN_EXPIRES"))
-	BackendURL = fmt.Sprintf("%s/api/v%s", os.Getenv("BASE_URL"), os.Getenv("VERSION"))
-	FrontendURl = os.Getenv("BASE_URL")
-	GiteaCommitEmail = os.Getenv("GITEA_COMMIT_EMAIL")
+// Init some usefil variables from the config
+func Init(cfg *config.Config) {
+	GiteaORGName = cfg.Gitea.OrgName
+	CookieRefreshTokenExpires = cfg.Cookie.RefreshTokenExpires
+	CookieAccessTokenExpires = cfg.Cookie.AccessTokenExpires
+	BackendURL = fmt.Sprintf("%s/api/v%s", cfg.App.BaseURL, cfg.App.Version)
+	FrontendURl = cfg.App.BaseURL
+	GiteaCommitEmail = cfg.Gitea.CommitEmail
+	CookieDomain = cfg.Cookie.Domain
+	CookiePath = cfg.Cookie.Path
+	CookieSameSite = parseSameSite(cfg.Cookie.SameSite)
+	AccountDeletionGracePeriod = cfg.Account.DeletionGracePeriod
+	ReserveDeletedEmails = cfg.Account.ReserveDeletedEmails
+	UserExportInterval = cfg.Export.Interval
+	AvatarMaxSize = cfg.Avatar.MaxSize
+	AvatarMaxDimension = cfg.Avatar.MaxDimension
+	UploadMaxSize = cfg.Upload.MaxSize
+	UploadURLTTL = cfg.Upload.URLTTL
+	secret = strings.HasPrefix(cfg.App.BaseURL, "https://")
+}
+
+// parseSameSite converts the COOKIE_SAME_SITE value to http.SameSite, defaulting to lax
+func parseSameSite(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
 }
 
 // Magic bytes for different image formats